@@ -0,0 +1,83 @@
+package barcode
+
+import (
+	"strings"
+	"testing"
+)
+
+// modulesString 将模块转换为 1/0 字符串便于比较
+func modulesString(modules []bool) string {
+	var b strings.Builder
+	for _, m := range modules {
+		if m {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+// patternString 将码值的条/空宽度展开为 1/0 字符串
+func patternString(code int) string {
+	var b strings.Builder
+	for i, w := range code128Patterns[code] {
+		b.WriteString(strings.Repeat(string("10"[i%2]), int(w-'0')))
+	}
+	return b.String()
+}
+
+func TestCode128(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// 起始符 B、A、校验码 34（B）、终止符
+		{"A", "11010010000" + "10100011000" + "10001011000" + "1100011101011"},
+	}
+	for _, tt := range tests {
+		modules, err := Code128(tt.input)
+		if err != nil {
+			t.Errorf("Code128(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got := modulesString(modules); got != tt.want {
+			t.Errorf("Code128(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCode128Checksum(t *testing.T) {
+	tests := []struct {
+		input    string
+		checksum int
+	}{
+		{"A", 34},
+		{"P123", 7},
+		{"PJJ123C", 55},
+		{"~", 95},
+	}
+	for _, tt := range tests {
+		modules, err := Code128(tt.input)
+		if err != nil {
+			t.Errorf("Code128(%q) error: %v", tt.input, err)
+			continue
+		}
+		if want := 11*(len(tt.input)+3) + 2; len(modules) != want {
+			t.Errorf("Code128(%q) has %d modules, want %d", tt.input, len(modules), want)
+			continue
+		}
+		start := 11 * (len(tt.input) + 1)
+		if got, want := modulesString(modules[start:start+11]), patternString(tt.checksum); got != want {
+			t.Errorf("Code128(%q) checksum modules = %s, want %s (code %d)", tt.input, got, want, tt.checksum)
+		}
+	}
+}
+
+func TestCode128Errors(t *testing.T) {
+	for _, input := range []string{"", "P\n1", "货号"} {
+		if _, err := Code128(input); err == nil {
+			t.Errorf("Code128(%q) want error", input)
+		}
+	}
+}
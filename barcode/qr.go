@@ -0,0 +1,383 @@
+package barcode
+
+import "fmt"
+
+// qrVersion 纠错等级 M 下各版本的码字分块
+type qrVersion struct {
+	blocks     int // 块数，1-6 版本每块数据码字数相同
+	dataPerBlk int // 每块数据码字数
+	ecPerBlk   int // 每块纠错码字数
+	align      int // 右下角校正图形中心坐标，0 表示无
+}
+
+// qrVersions 版本 1-6，纠错等级 M，字节模式最多 106 字节
+var qrVersions = []qrVersion{
+	{1, 16, 10, 0},
+	{1, 28, 16, 18},
+	{1, 44, 26, 22},
+	{2, 32, 18, 26},
+	{2, 43, 24, 30},
+	{4, 27, 16, 34},
+}
+
+// qrFormatM 纠错等级 M 的格式信息编码
+const qrFormatM = 0
+
+// QR 以字节模式、纠错等级 M 编码字符串，返回 [行][列] 模块矩阵，true 为深色
+//
+// 自动选择能容纳内容的最小版本（1-6），不含四周静区，绘制时至少留 4 个模块宽的空白
+func QR(s string) ([][]bool, error) {
+	data := []byte(s)
+	version := 0
+	for i, v := range qrVersions {
+		// 模式 4 位 + 长度 8 位
+		if 4+8+len(data)*8 <= v.blocks*v.dataPerBlk*8 {
+			version = i + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("二维码内容过长: %d 字节", len(data))
+	}
+	v := qrVersions[version-1]
+
+	codewords := qrInterleave(qrEncodeData(data, v.blocks*v.dataPerBlk), v)
+
+	q := newQRMatrix(version)
+	q.drawFunctionPatterns(v.align)
+	q.drawCodewords(codewords)
+
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); best < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q.modules, nil
+}
+
+// qrEncodeData 生成字节模式的数据码字，不足容量时补齐
+func qrEncodeData(data []byte, capacity int) []byte {
+	var bits []bool
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>i)&1 == 1)
+		}
+	}
+
+	appendBits(0x4, 4)
+	appendBits(len(data), 8)
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+
+	// 终止符最多 4 位，再补齐到整字节
+	for i := 0; i < 4 && len(bits) < capacity*8; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	out := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// qrInterleave 分块计算纠错码，并按规范交错排列
+func qrInterleave(data []byte, v qrVersion) []byte {
+	divisor := rsDivisor(v.ecPerBlk)
+	blocks := make([][]byte, v.blocks)
+	ecs := make([][]byte, v.blocks)
+	for i := range blocks {
+		blocks[i] = data[i*v.dataPerBlk : (i+1)*v.dataPerBlk]
+		ecs[i] = rsRemainder(blocks[i], divisor)
+	}
+
+	out := make([]byte, 0, v.blocks*(v.dataPerBlk+v.ecPerBlk))
+	for i := 0; i < v.dataPerBlk; i++ {
+		for _, b := range blocks {
+			out = append(out, b[i])
+		}
+	}
+	for i := 0; i < v.ecPerBlk; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// gfMul GF(2^8) 乘法，本原多项式 0x11D
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor Reed-Solomon 生成多项式系数（不含最高次项）
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder 计算数据的纠错码字
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+type qrMatrix struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := version*4 + 17
+	q := &qrMatrix{size: size, modules: make([][]bool, size), isFunction: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.isFunction[i] = make([]bool, size)
+	}
+	return q
+}
+
+func (q *qrMatrix) setFunction(row, col int, dark bool) {
+	q.modules[row][col] = dark
+	q.isFunction[row][col] = true
+}
+
+// drawFunctionPatterns 绘制定位、时序、校正图形并预留格式信息区域
+func (q *qrMatrix) drawFunctionPatterns(align int) {
+	for i := 0; i < q.size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	finder := func(row, col int) {
+		for dr := -4; dr <= 4; dr++ {
+			for dc := -4; dc <= 4; dc++ {
+				r, c := row+dr, col+dc
+				if r < 0 || r >= q.size || c < 0 || c >= q.size {
+					continue
+				}
+				d := max(abs(dr), abs(dc))
+				q.setFunction(r, c, d != 2 && d != 4)
+			}
+		}
+	}
+	finder(3, 3)
+	finder(3, q.size-4)
+	finder(q.size-4, 3)
+
+	if align > 0 {
+		for dr := -2; dr <= 2; dr++ {
+			for dc := -2; dc <= 2; dc++ {
+				q.setFunction(align+dr, align+dc, max(abs(dr), abs(dc)) != 1)
+			}
+		}
+	}
+
+	// 格式信息先占位，选定掩码后再写入
+	q.drawFormat(0)
+}
+
+// drawFormat 写入纠错等级与掩码的格式信息（两份）及固定深色模块
+func (q *qrMatrix) drawFormat(mask int) {
+	data := qrFormatM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(i, 8, bit(i))
+	}
+	q.setFunction(7, 8, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(8, 14-i, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(8, q.size-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(q.size-15+i, 8, bit(i))
+	}
+	q.setFunction(q.size-8, 8, true)
+}
+
+// drawCodewords 按之字形从右下角开始填充数据模块
+func (q *qrMatrix) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				col := right - j
+				row := vert
+				if (right+1)&2 == 0 {
+					row = q.size - 1 - vert
+				}
+				if !q.isFunction[row][col] && i < len(data)*8 {
+					q.modules[row][col] = (data[i>>3]>>(7-uint(i&7)))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask 对数据模块异或掩码，再次调用即可撤销
+func (q *qrMatrix) applyMask(mask int) {
+	for r := 0; r < q.size; r++ {
+		for c := 0; c < q.size; c++ {
+			if q.isFunction[r][c] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (r+c)%2 == 0
+			case 1:
+				invert = r%2 == 0
+			case 2:
+				invert = c%3 == 0
+			case 3:
+				invert = (r+c)%3 == 0
+			case 4:
+				invert = (r/2+c/3)%2 == 0
+			case 5:
+				invert = r*c%2+r*c%3 == 0
+			case 6:
+				invert = (r*c%2+r*c%3)%2 == 0
+			case 7:
+				invert = ((r+c)%2+r*c%3)%2 == 0
+			}
+			if invert {
+				q.modules[r][c] = !q.modules[r][c]
+			}
+		}
+	}
+}
+
+// penalty 按规范的四条规则计算掩码罚分，用于选择掩码
+func (q *qrMatrix) penalty() int {
+	n := q.size
+	at := func(r, c int, transpose bool) bool {
+		if transpose {
+			return q.modules[c][r]
+		}
+		return q.modules[r][c]
+	}
+
+	total := 0
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, transpose := range []bool{false, true} {
+		for r := 0; r < n; r++ {
+			// 规则1：同色连续5个及以上
+			run := 1
+			for c := 1; c <= n; c++ {
+				if c < n && at(r, c, transpose) == at(r, c-1, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					total += 3 + run - 5
+				}
+				run = 1
+			}
+			// 规则3：类似定位图形的 1:1:3:1:1 序列
+			for c := 0; c+11 <= n; c++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, dark := range pattern {
+						if at(r, c+k, transpose) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						total += 40
+					}
+				}
+			}
+		}
+	}
+
+	// 规则2：2x2 同色块
+	dark := 0
+	for r := 0; r < n; r++ {
+		for c := 0; c < n; c++ {
+			if q.modules[r][c] {
+				dark++
+			}
+			if r+1 < n && c+1 < n {
+				v := q.modules[r][c]
+				if q.modules[r][c+1] == v && q.modules[r+1][c] == v && q.modules[r+1][c+1] == v {
+					total += 3
+				}
+			}
+		}
+	}
+
+	// 规则4：深色比例偏离50%
+	percent := dark * 100 / (n * n)
+	total += abs(percent-50) / 5 * 10
+	return total
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,141 @@
+package barcode
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestQRVersion(t *testing.T) {
+	tests := []struct {
+		length int
+		size   int
+	}{
+		{0, 21},
+		{14, 21},
+		{15, 25},
+		{26, 25},
+		{27, 29},
+		{42, 29},
+		{43, 33},
+		{62, 33},
+		{63, 37},
+		{84, 37},
+		{85, 41},
+		{106, 41},
+	}
+	for _, tt := range tests {
+		modules, err := QR(strings.Repeat("a", tt.length))
+		if err != nil {
+			t.Errorf("QR(%d bytes) error: %v", tt.length, err)
+			continue
+		}
+		if len(modules) != tt.size || len(modules[0]) != tt.size {
+			t.Errorf("QR(%d bytes) size = %dx%d, want %dx%d", tt.length, len(modules), len(modules[0]), tt.size, tt.size)
+		}
+	}
+
+	if _, err := QR(strings.Repeat("a", 107)); err == nil {
+		t.Error("QR(107 bytes) want error")
+	}
+}
+
+func TestQRFormat(t *testing.T) {
+	// 纠错等级 M 各掩码的格式信息，高位在前
+	tests := []struct {
+		mask int
+		want string
+	}{
+		{0, "101010000010010"},
+		{1, "101000100100101"},
+		{2, "101111001111100"},
+		{3, "101101101001011"},
+		{4, "100010111111001"},
+		{5, "100000011001110"},
+		{6, "100111110010111"},
+		{7, "100101010100000"},
+	}
+	for _, tt := range tests {
+		q := newQRMatrix(1)
+		q.drawFormat(tt.mask)
+
+		var first, second int
+		for i := 0; i < 15; i++ {
+			var r1, c1, r2, c2 int
+			switch {
+			case i <= 5:
+				r1, c1 = i, 8
+			case i == 6:
+				r1, c1 = 7, 8
+			case i == 7:
+				r1, c1 = 8, 8
+			case i == 8:
+				r1, c1 = 8, 7
+			default:
+				r1, c1 = 8, 14-i
+			}
+			if i < 8 {
+				r2, c2 = 8, q.size-1-i
+			} else {
+				r2, c2 = q.size-15+i, 8
+			}
+			if q.modules[r1][c1] {
+				first |= 1 << i
+			}
+			if q.modules[r2][c2] {
+				second |= 1 << i
+			}
+		}
+
+		want, _ := strconv.ParseInt(tt.want, 2, 32)
+		if first != int(want) || second != int(want) {
+			t.Errorf("drawFormat(%d) = %015b/%015b, want %s", tt.mask, first, second, tt.want)
+		}
+		if !q.modules[q.size-8][8] {
+			t.Errorf("drawFormat(%d) missing dark module", tt.mask)
+		}
+	}
+}
+
+func TestQREncodeData(t *testing.T) {
+	got := qrEncodeData([]byte("A"), 16)
+	want := []byte{0x40, 0x14, 0x10,
+		0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC}
+	if !bytes.Equal(got, want) {
+		t.Errorf("qrEncodeData(A) = % X, want % X", got, want)
+	}
+
+	// 内容占满容量时终止符被截断，不再补齐
+	if got := qrEncodeData(bytes.Repeat([]byte("a"), 14), 16); len(got) != 16 || got[15]&0x0F != 0 {
+		t.Errorf("qrEncodeData(14 bytes) = % X", got)
+	}
+}
+
+func TestQRCodewords(t *testing.T) {
+	// 版本 1-M 的纠错码示例，数据为字母数字模式的 "HELLO WORLD"
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := qrInterleave(data, qrVersions[0])
+	if !bytes.Equal(got[:16], data) || !bytes.Equal(got[16:], want) {
+		t.Errorf("qrInterleave(HELLO WORLD) = %v, want %v + %v", got, data, want)
+	}
+
+	// 两块时数据码字与纠错码字分别交错
+	v := qrVersions[3]
+	data = make([]byte, v.blocks*v.dataPerBlk)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	got = qrInterleave(data, v)
+	if len(got) != v.blocks*(v.dataPerBlk+v.ecPerBlk) {
+		t.Fatalf("qrInterleave(version 4) has %d codewords", len(got))
+	}
+	for i := 0; i < v.dataPerBlk; i++ {
+		if got[2*i] != byte(i) || got[2*i+1] != byte(v.dataPerBlk+i) {
+			t.Errorf("qrInterleave(version 4) codewords %d,%d = %d,%d", 2*i, 2*i+1, got[2*i], got[2*i+1])
+			break
+		}
+	}
+}
@@ -8,9 +8,14 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Upload   UploadConfig   `yaml:"upload"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Upload    UploadConfig    `yaml:"upload"`
+	Duplicate DuplicateConfig `yaml:"duplicate"`
+	Statement StatementConfig `yaml:"statement"`
+	Shipping  ShippingConfig  `yaml:"shipping"`
+	Labels    LabelConfig     `yaml:"labels"`
+	Pricing   PricingConfig   `yaml:"pricing"`
 }
 
 type ServerConfig struct {
@@ -31,6 +36,73 @@ type UploadConfig struct {
 	MaxSize int64  `yaml:"max_size"`
 }
 
+type DuplicateConfig struct {
+	WindowDays int `yaml:"window_days"` // 同客户同品牌同尺码视为重复的天数窗口
+}
+
+type StatementConfig struct {
+	Title  string `yaml:"title"`  // 对账单标题
+	Header string `yaml:"header"` // 页眉，如店铺名称与联系方式
+	Footer string `yaml:"footer"` // 页脚，如收款方式
+}
+
+type ShippingConfig struct {
+	VolumetricDivisor float64    `yaml:"volumetric_divisor"` // 体积重除数（cm³/kg），默认6000
+	DefaultRoute      string     `yaml:"default_route"`      // 商品未指定线路时使用的线路
+	Routes            []RateCard `yaml:"routes"`             // 各线路运价表
+}
+
+// RateCard 一条运输线路的运价，按计费重量落入的档位计价
+type RateCard struct {
+	Name      string     `yaml:"name" json:"name"`
+	MinCharge float64    `yaml:"min_charge" json:"min_charge"` // 最低收费RMB
+	Tiers     []RateTier `yaml:"tiers" json:"tiers"`           // 按 UpToKg 从小到大排列
+}
+
+type RateTier struct {
+	UpToKg float64 `yaml:"up_to_kg" json:"up_to_kg"` // 档位上限kg，0 表示不限
+	PerKg  float64 `yaml:"per_kg" json:"per_kg"`     // 每公斤单价RMB
+}
+
+// PricingConfig 商品成本与利润的计算参数，比例均为百分数
+type PricingConfig struct {
+	VatRefundRate   float64    `yaml:"vat_refund_rate" json:"vat_refund_rate"`   // 退税比例，按采购成本RMB计算，冲减成本
+	CardFeeRate     float64    `yaml:"card_fee_rate" json:"card_fee_rate"`       // 刷卡手续费比例，按采购成本RMB计算
+	CommissionRate  float64    `yaml:"commission_rate" json:"commission_rate"`   // 佣金比例，按售价RMB计算
+	CommissionFixed float64    `yaml:"commission_fixed" json:"commission_fixed"` // 每件商品的固定佣金RMB
+	ExtraCosts      []CostItem `yaml:"extra_costs" json:"extra_costs"`           // 其他成本项
+}
+
+// CostItem 其他成本项，Base 为 cost 时按采购成本、price 时按售价乘以 Rate，另加固定金额 Amount
+type CostItem struct {
+	Name   string  `yaml:"name" json:"name"`
+	Base   string  `yaml:"base" json:"base"`
+	Rate   float64 `yaml:"rate" json:"rate"`     // 比例
+	Amount float64 `yaml:"amount" json:"amount"` // 固定金额RMB
+}
+
+type LabelConfig struct {
+	DefaultTemplate string          `yaml:"default_template"` // 未指定模板时使用
+	Templates       []LabelTemplate `yaml:"templates"`
+}
+
+// LabelTemplate 标签模板，尺寸单位为毫米；Columns 与 Rows 大于0时按 A4 整张排版
+type LabelTemplate struct {
+	Name       string  `yaml:"name" json:"name"`
+	Width      float64 `yaml:"width" json:"width"`             // 标签宽
+	Height     float64 `yaml:"height" json:"height"`           // 标签高
+	Columns    int     `yaml:"columns" json:"columns"`         // A4 每行标签数，0 表示每页一张
+	Rows       int     `yaml:"rows" json:"rows"`               // A4 每列标签数
+	MarginTop  float64 `yaml:"margin_top" json:"margin_top"`   // A4 上边距
+	MarginLeft float64 `yaml:"margin_left" json:"margin_left"` // A4 左边距
+	GapX       float64 `yaml:"gap_x" json:"gap_x"`             // 标签水平间距
+	GapY       float64 `yaml:"gap_y" json:"gap_y"`             // 标签垂直间距
+	DPI        int     `yaml:"dpi" json:"dpi"`                 // 热敏打印机分辨率，默认203
+	Font       string  `yaml:"font" json:"font"`               // 热敏打印机中文字体，如 E:SIMSUN.FNT，为空时用内置字体
+	Code128    bool    `yaml:"code128" json:"code128"`         // 打印 Code128 条码
+	QR         bool    `yaml:"qr" json:"qr"`                   // 打印二维码
+}
+
 var GlobalConfig *Config
 
 func LoadConfig(path string) error {
@@ -0,0 +1,12 @@
+-- 到货明细：到货记录与商品的关联
+
+-- 到货明细表（到货记录与商品的关联）
+CREATE TABLE IF NOT EXISTS `cc_arrival_item` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `arrival_id` INT NOT NULL COMMENT '到货记录ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `user_id` INT NOT NULL COMMENT '操作用户ID',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY `uk_arrival_product` (`arrival_id`, `product_id`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='到货明细表';
@@ -0,0 +1,5 @@
+-- 商品照片哈希，用于重复检测
+
+ALTER TABLE `cc_product`
+  ADD COLUMN `photo_hash` CHAR(64) NOT NULL DEFAULT '' COMMENT '照片内容哈希（重复检测）' AFTER `status_note_photo`,
+  ADD KEY `idx_photo_hash` (`photo_hash`);
@@ -0,0 +1,4 @@
+-- 商品搜索全文索引（需要 MySQL 5.7.6 及以上的 ngram 分词）
+
+ALTER TABLE `cc_product`
+  ADD FULLTEXT KEY `ft_product_search` (`customer_name`, `brand`, `size`, `address`, `mark`) WITH PARSER ngram;
@@ -0,0 +1,19 @@
+-- 列表视图
+
+-- 列表视图表
+CREATE TABLE IF NOT EXISTS `cc_saved_view` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '用户ID',
+  `list_type` VARCHAR(20) NOT NULL DEFAULT 'product' COMMENT '列表类型 product/arrival',
+  `name` VARCHAR(100) NOT NULL COMMENT '视图名称',
+  `filter` TEXT NOT NULL COMMENT '筛选条件JSON',
+  `order_by` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '排序字段',
+  `order_dir` VARCHAR(4) NOT NULL DEFAULT 'DESC' COMMENT '排序方向',
+  `columns` TEXT NOT NULL COMMENT '可见列JSON',
+  `page_size` INT NOT NULL DEFAULT 0 COMMENT '每页条数，0为不指定',
+  `is_shared` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否共享给其他用户',
+  `is_default` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否为默认视图',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_user_list` (`user_id`, `list_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='列表视图表';
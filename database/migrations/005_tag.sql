@@ -0,0 +1,21 @@
+-- 商品标签
+
+-- 标签表
+CREATE TABLE IF NOT EXISTS `cc_tag` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建用户ID',
+  `name` VARCHAR(50) NOT NULL COMMENT '标签名称',
+  `color` VARCHAR(20) NOT NULL DEFAULT '#909399' COMMENT '标签颜色',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='标签表';
+
+-- 商品标签关联表
+CREATE TABLE IF NOT EXISTS `cc_product_tag` (
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `tag_id` INT NOT NULL COMMENT '标签ID',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`product_id`, `tag_id`),
+  KEY `idx_tag_id` (`tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品标签关联表';
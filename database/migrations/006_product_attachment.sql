@@ -0,0 +1,29 @@
+-- 商品附件
+
+-- 商品附件表
+CREATE TABLE IF NOT EXISTS `cc_product_attachment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `user_id` INT NOT NULL COMMENT '上传用户ID',
+  `kind` VARCHAR(20) NOT NULL DEFAULT 'product' COMMENT '类型 product/receipt/status/damage',
+  `url` VARCHAR(500) NOT NULL COMMENT '文件URL',
+  `caption` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '说明',
+  `sort_order` INT NOT NULL DEFAULT 0 COMMENT '排序',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY `idx_product_id` (`product_id`, `sort_order`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品附件表';
+
+-- 已有商品的主图和状态图补录为附件，保证附件列表包含当前主图
+INSERT INTO `cc_product_attachment` (`product_id`, `user_id`, `kind`, `url`, `sort_order`)
+SELECT p.`id`, p.`user_id`, 'product', p.`photo`, 1
+FROM `cc_product` p
+WHERE COALESCE(p.`photo`, '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM `cc_product_attachment` a
+    WHERE a.`product_id` = p.`id` AND a.`kind` = 'product' AND a.`url` = p.`photo`);
+
+INSERT INTO `cc_product_attachment` (`product_id`, `user_id`, `kind`, `url`, `sort_order`)
+SELECT p.`id`, p.`user_id`, 'status', p.`status_note_photo`, 2
+FROM `cc_product` p
+WHERE COALESCE(p.`status_note_photo`, '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM `cc_product_attachment` a
+    WHERE a.`product_id` = p.`id` AND a.`kind` = 'status' AND a.`url` = p.`status_note_photo`);
@@ -0,0 +1,24 @@
+-- 评论与@提及
+
+-- 评论表
+CREATE TABLE IF NOT EXISTS `cc_comment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `target_type` VARCHAR(20) NOT NULL COMMENT '对象类型 product/arrival',
+  `target_id` INT NOT NULL COMMENT '对象ID',
+  `parent_id` INT NOT NULL DEFAULT 0 COMMENT '顶层评论ID，0为顶层',
+  `user_id` INT NOT NULL COMMENT '作者ID',
+  `content` TEXT NOT NULL COMMENT '内容',
+  `images` TEXT NOT NULL COMMENT '图片URL列表JSON',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY `idx_target` (`target_type`, `target_id`),
+  KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='评论表';
+
+-- 评论提及表
+CREATE TABLE IF NOT EXISTS `cc_comment_mention` (
+  `comment_id` INT NOT NULL COMMENT '评论ID',
+  `user_id` INT NOT NULL COMMENT '被提及用户ID',
+  `read_at` TIMESTAMP NULL DEFAULT NULL COMMENT '已读时间',
+  PRIMARY KEY (`comment_id`, `user_id`),
+  KEY `idx_user_unread` (`user_id`, `read_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='评论提及表';
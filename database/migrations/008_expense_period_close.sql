@@ -0,0 +1,21 @@
+-- 费用与月份结账
+
+-- 其他费用表（月度损益）
+CREATE TABLE IF NOT EXISTS `cc_expense` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '登记人ID',
+  `month` CHAR(7) NOT NULL COMMENT '所属月份 YYYY-MM',
+  `category` VARCHAR(50) NOT NULL COMMENT '费用类别',
+  `amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '金额RMB',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_month` (`month`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='其他费用表';
+
+-- 月份结账表
+CREATE TABLE IF NOT EXISTS `cc_period_close` (
+  `month` CHAR(7) PRIMARY KEY COMMENT '已结账月份 YYYY-MM',
+  `user_id` INT NOT NULL COMMENT '结账人ID',
+  `closed_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '结账时间'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='月份结账表';
@@ -0,0 +1,28 @@
+-- 运单与运费分摊
+
+-- 运单表（合并发货的国际运输）
+CREATE TABLE IF NOT EXISTS `cc_shipment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `name` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '运单名称',
+  `tracking_no` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '运单号',
+  `freight` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '国际运费RMB',
+  `customs` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '清关费RMB',
+  `method` VARCHAR(20) NOT NULL DEFAULT 'quantity' COMMENT '分摊方式 quantity/weight/value/manual',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `allocated_at` TIMESTAMP NULL DEFAULT NULL COMMENT '最近分摊时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='运单表';
+
+-- 运单明细表
+CREATE TABLE IF NOT EXISTS `cc_shipment_item` (
+  `shipment_id` INT NOT NULL COMMENT '运单ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '重量kg（按重量分摊）',
+  `ratio` DECIMAL(10,4) NOT NULL DEFAULT 0.0000 COMMENT '手动分摊比例',
+  `allocated` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '已分摊金额',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`shipment_id`, `product_id`),
+  UNIQUE KEY `uk_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='运单明细表';
@@ -0,0 +1,4 @@
+-- 商品付款状态
+
+ALTER TABLE `cc_product`
+  ADD COLUMN `paid` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '客户是否已付款' AFTER `photo_hash`;
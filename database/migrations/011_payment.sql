@@ -0,0 +1,24 @@
+-- 收款记录与客户余额
+
+ALTER TABLE `cc_product`
+  MODIFY COLUMN `paid` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '客户是否已付清（由收款记录计算）',
+  ADD COLUMN `paid_amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '已付金额（由收款记录计算）' AFTER `paid`,
+  ADD KEY `idx_customer_name` (`customer_name`);
+
+-- 收款表
+CREATE TABLE IF NOT EXISTS `cc_payment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '登记人ID',
+  `product_id` INT NOT NULL DEFAULT 0 COMMENT '商品ID，0表示记在客户名下',
+  `customer_name` VARCHAR(200) NOT NULL COMMENT '客户名',
+  `kind` VARCHAR(20) NOT NULL DEFAULT 'balance' COMMENT '类型 deposit/balance/refund',
+  `method` VARCHAR(30) NOT NULL DEFAULT '' COMMENT '支付方式',
+  `amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '金额RMB',
+  `paid_at` DATETIME NOT NULL COMMENT '收款时间',
+  `reference` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '流水号/凭证号',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_customer_name` (`customer_name`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='收款表';
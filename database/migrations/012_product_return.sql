@@ -0,0 +1,22 @@
+-- 商品退货
+
+ALTER TABLE `cc_product`
+  ADD COLUMN `refunded_amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退给客户的金额（由退货记录计算）' AFTER `paid_amount`,
+  ADD COLUMN `return_adjustment` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退货对利润的调整（由退货记录计算）' AFTER `refunded_amount`;
+
+-- 商品退货表
+CREATE TABLE IF NOT EXISTS `cc_product_return` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `user_id` INT NOT NULL COMMENT '登记人ID',
+  `reason` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '退货原因',
+  `refund_to_customer` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退给客户的金额RMB',
+  `refund_from_supplier` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '供应商退款RMB',
+  `restocking_fee` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退货手续费RMB',
+  `photos` TEXT NOT NULL COMMENT '退货照片URL（JSON数组）',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `returned_at` DATETIME NOT NULL COMMENT '退货时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品退货表';
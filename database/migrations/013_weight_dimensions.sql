@@ -0,0 +1,18 @@
+-- 重量、尺寸与运费估算
+
+ALTER TABLE `cc_product`
+  ADD COLUMN `shipping_confirmed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '运费已确认，0表示按重量尺寸自动估算' AFTER `shipping_fee`,
+  ADD COLUMN `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '实际重量kg' AFTER `shipping_confirmed`,
+  ADD COLUMN `length` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '长cm' AFTER `weight`,
+  ADD COLUMN `width` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '宽cm' AFTER `length`,
+  ADD COLUMN `height` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '高cm' AFTER `width`,
+  ADD COLUMN `route` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '运输线路，空表示默认线路' AFTER `height`;
+
+-- 已填写运费的商品视为已确认，避免升级后被自动估算覆盖
+UPDATE `cc_product` SET `shipping_confirmed`=1 WHERE `shipping_fee` > 0;
+
+ALTER TABLE `cc_arrival`
+  ADD COLUMN `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '到货重量kg' AFTER `confirm_person`,
+  ADD COLUMN `length` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '箱子长cm' AFTER `weight`,
+  ADD COLUMN `width` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '箱子宽cm' AFTER `length`,
+  ADD COLUMN `height` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '箱子高cm' AFTER `width`;
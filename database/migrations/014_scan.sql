@@ -0,0 +1,30 @@
+-- 扫码分拣
+
+ALTER TABLE `cc_product`
+  ADD COLUMN `sorted_at` DATETIME DEFAULT NULL COMMENT '扫码分拣时间，NULL表示未分拣' AFTER `route`;
+
+-- 分拣会话表
+CREATE TABLE IF NOT EXISTS `cc_scan_session` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `name` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '会话名称',
+  `arrival_id` INT NOT NULL DEFAULT 0 COMMENT '应扫范围：到货记录ID',
+  `shipment_id` INT NOT NULL DEFAULT 0 COMMENT '应扫范围：运单ID',
+  `closed_at` DATETIME DEFAULT NULL COMMENT '结束时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分拣会话表';
+
+-- 扫码记录表
+CREATE TABLE IF NOT EXISTS `cc_scan_event` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `session_id` INT NOT NULL DEFAULT 0 COMMENT '分拣会话ID，0表示不属于会话',
+  `user_id` INT NOT NULL COMMENT '扫码人ID',
+  `code` VARCHAR(200) NOT NULL COMMENT '扫描的条码',
+  `matched_by` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '匹配方式 product/box/tracking，空表示未找到',
+  `product_id` INT NOT NULL DEFAULT 0 COMMENT '唯一匹配的商品ID',
+  `area_id` INT NOT NULL DEFAULT 0 COMMENT '商品所在区域ID',
+  `sorted` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否标记为已分拣',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY `idx_session_id` (`session_id`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='扫码记录表';
@@ -0,0 +1,23 @@
+-- 拣货单
+
+-- 拣货单表
+CREATE TABLE IF NOT EXISTS `cc_pick_list` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `name` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '名称',
+  `arrival_id` INT NOT NULL DEFAULT 0 COMMENT '到货记录ID，0表示按到货日期范围生成',
+  `start_time` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '到货日期开始',
+  `end_time` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '到货日期结束',
+  `completed_at` DATETIME DEFAULT NULL COMMENT '全部扫码完成时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='拣货单表';
+
+-- 拣货单明细表
+CREATE TABLE IF NOT EXISTS `cc_pick_list_item` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `pick_list_id` INT NOT NULL COMMENT '拣货单ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `scanned_at` DATETIME DEFAULT NULL COMMENT '扫码时间',
+  UNIQUE KEY `uk_list_product` (`pick_list_id`, `product_id`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='拣货单明细表';
@@ -0,0 +1,9 @@
+-- 成本与利润计算：退税、刷卡手续费、佣金和其他成本
+
+ALTER TABLE `cc_product`
+  ADD COLUMN `vat_refund` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退税（自动计算，冲减成本）' AFTER `shipping_fee`,
+  ADD COLUMN `card_fee` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '刷卡手续费（自动计算）' AFTER `vat_refund`,
+  ADD COLUMN `commission` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '佣金（自动计算）' AFTER `card_fee`,
+  ADD COLUMN `other_cost` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '其他成本（自动计算）' AFTER `commission`;
+
+-- 升级后调用 POST /api/products/recalculate 按新参数重算未结账月份的商品
@@ -0,0 +1,24 @@
+-- 品牌目录
+
+ALTER TABLE `cc_product`
+  MODIFY COLUMN `brand` VARCHAR(512) DEFAULT NULL COMMENT '品牌';
+
+-- 品牌目录表
+CREATE TABLE IF NOT EXISTS `cc_brand` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `name` VARCHAR(200) NOT NULL COMMENT '规范品牌名称',
+  `currency` CHAR(3) NOT NULL DEFAULT 'EUR' COMMENT '默认采购币种',
+  `supplier` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '默认供应商',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='品牌目录表';
+
+-- 品牌别名表（写入时别名统一为品牌名称）
+CREATE TABLE IF NOT EXISTS `cc_brand_alias` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `brand_id` INT NOT NULL COMMENT '品牌ID',
+  `alias` VARCHAR(200) NOT NULL COMMENT '别名，不区分大小写',
+  UNIQUE KEY `uk_alias` (`alias`),
+  KEY `idx_brand_id` (`brand_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='品牌别名表';
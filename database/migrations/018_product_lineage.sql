@@ -0,0 +1,21 @@
+-- 商品拆分与合并记录
+
+-- 商品拆分合并记录表
+CREATE TABLE IF NOT EXISTS `cc_product_history` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `action` VARCHAR(20) NOT NULL COMMENT '操作 split/merge',
+  `user_id` INT NOT NULL COMMENT '操作用户ID',
+  `snapshot` MEDIUMTEXT NOT NULL COMMENT '操作前商品的JSON快照',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品拆分合并记录表';
+
+-- 商品来源关系表
+CREATE TABLE IF NOT EXISTS `cc_product_lineage` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `history_id` INT NOT NULL COMMENT '拆分合并记录ID',
+  `from_id` INT NOT NULL COMMENT '来源商品ID',
+  `to_id` INT NOT NULL COMMENT '结果商品ID',
+  KEY `idx_history_id` (`history_id`),
+  KEY `idx_from_id` (`from_id`),
+  KEY `idx_to_id` (`to_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品来源关系表';
@@ -0,0 +1,31 @@
+-- 发货包裹
+
+ALTER TABLE `cc_product`
+  ADD COLUMN `outbound_status` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '发货状态 packed/shipped/delivered，空表示未装入包裹' AFTER `sorted_at`;
+
+-- 发货包裹表（分拣后寄给客户的国内快递）
+CREATE TABLE IF NOT EXISTS `cc_parcel` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `carrier` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '快递公司',
+  `tracking_no` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '快递单号',
+  `recipient` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '收件人',
+  `address` TEXT COMMENT '收件地址',
+  `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '包裹重量kg',
+  `cost` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '快递费RMB',
+  `status` VARCHAR(20) NOT NULL DEFAULT 'packed' COMMENT '状态 packed/shipped/delivered',
+  `shipped_date` DATE DEFAULT NULL COMMENT '发货日期',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_tracking_no` (`tracking_no`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='发货包裹表';
+
+-- 发货包裹明细表
+CREATE TABLE IF NOT EXISTS `cc_parcel_item` (
+  `parcel_id` INT NOT NULL COMMENT '包裹ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`parcel_id`, `product_id`),
+  UNIQUE KEY `uk_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='发货包裹明细表';
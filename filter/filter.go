@@ -0,0 +1,311 @@
+// Package filter 将结构化的列表筛选条件编译为参数化的 SQL 条件，
+// 供商品、到货列表以及批量操作共用
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sorting-system/search"
+	"strconv"
+	"strings"
+)
+
+// Range 数值或日期范围，两端均可为空，值可以是数字或字符串
+type Range struct {
+	Min interface{} `json:"min"`
+	Max interface{} `json:"max"`
+}
+
+// Filter 列表筛选条件，各条件之间为 AND 关系
+type Filter struct {
+	// Keyword 搜索框语法，见 search 包
+	Keyword string `json:"keyword"`
+	// AreaIDs 区域ID列表，等价于 In["area_id"]
+	AreaIDs []int `json:"area_ids"`
+	// TagIDs 带有其中任一标签
+	TagIDs []int `json:"tag_ids"`
+	// In 字段取值在列表中
+	In map[string][]interface{} `json:"in"`
+	// Ranges 字段的数值或日期范围
+	Ranges map[string]Range `json:"ranges"`
+	// Null 字段为空（文本字段空字符串也视为空）
+	Null []string `json:"null"`
+	// NotNull 字段不为空
+	NotNull []string `json:"not_null"`
+}
+
+// IsEmpty 是否没有任何条件；空的取值列表、两端都为空的范围和只有空引号的搜索不算条件
+func (f *Filter) IsEmpty() bool {
+	if f == nil {
+		return true
+	}
+	if len(f.AreaIDs) > 0 || len(f.TagIDs) > 0 || len(f.Null) > 0 || len(f.NotNull) > 0 {
+		return false
+	}
+	if terms, err := search.Parse(f.Keyword); err != nil || len(terms) > 0 {
+		return false
+	}
+	for _, vals := range f.In {
+		if len(vals) > 0 {
+			return false
+		}
+	}
+	for _, r := range f.Ranges {
+		if bound(r.Min) != "" || bound(r.Max) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// SetRange 设置字段范围的一端，值为空字符串时忽略
+func (f *Filter) SetRange(field string, min, max string) {
+	if min == "" && max == "" {
+		return
+	}
+	if f.Ranges == nil {
+		f.Ranges = map[string]Range{}
+	}
+	r := f.Ranges[field]
+	if min != "" {
+		r.Min = min
+	}
+	if max != "" {
+		r.Max = max
+	}
+	f.Ranges[field] = r
+}
+
+// HasRange 字段是否已设置范围
+func (f *Filter) HasRange(field string) bool {
+	_, ok := f.Ranges[field]
+	return ok
+}
+
+// FromQuery 从查询参数构建筛选条件
+//
+//	keyword=...                    搜索框语法
+//	area_id=1&area_id=2            多个区域
+//	tag_id=1&tag_id=2              带有任一标签
+//	start_time=...&end_time=...    timeField 的范围
+//	in.brand=LV&in.brand=Gucci     取值列表
+//	min.profit=100&max.profit=500  数值/日期范围
+//	null=photo&not_null=address    空/非空检查
+func FromQuery(values url.Values, timeField string) (*Filter, error) {
+	f := &Filter{
+		Keyword: values.Get("keyword"),
+		Null:    values["null"],
+		NotNull: values["not_null"],
+	}
+
+	for _, v := range values["area_id"] {
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, &search.Error{Term: "area_id", Msg: "需要数字: " + v}
+		}
+		f.AreaIDs = append(f.AreaIDs, id)
+	}
+
+	for _, v := range values["tag_id"] {
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, &search.Error{Term: "tag_id", Msg: "需要数字: " + v}
+		}
+		f.TagIDs = append(f.TagIDs, id)
+	}
+
+	f.SetRange(timeField, values.Get("start_time"), values.Get("end_time"))
+
+	for key, vals := range values {
+		switch {
+		case strings.HasPrefix(key, "in."):
+			field := strings.TrimPrefix(key, "in.")
+			if f.In == nil {
+				f.In = map[string][]interface{}{}
+			}
+			for _, v := range vals {
+				f.In[field] = append(f.In[field], v)
+			}
+		case strings.HasPrefix(key, "min."):
+			f.SetRange(strings.TrimPrefix(key, "min."), vals[0], "")
+		case strings.HasPrefix(key, "max."):
+			f.SetRange(strings.TrimPrefix(key, "max."), "", vals[0])
+		}
+	}
+
+	return f, nil
+}
+
+// Compile 按表结构生成 WHERE 条件（不含前导 AND），无条件时返回空字符串
+// 字段或取值错误时返回 *search.Error
+func (f *Filter) Compile(s *search.Schema) (string, []interface{}, error) {
+	clauses := []string{}
+	args := []interface{}{}
+	if f == nil {
+		return "", args, nil
+	}
+
+	if f.Keyword != "" {
+		clause, keywordArgs, err := s.Compile(f.Keyword)
+		if err != nil {
+			return "", nil, err
+		}
+		if clause != "" {
+			clauses = append(clauses, clause)
+			args = append(args, keywordArgs...)
+		}
+	}
+
+	if len(f.TagIDs) > 0 {
+		if s.TagClause == "" {
+			return "", nil, &search.Error{Term: "tag_ids", Msg: "该列表不支持标签筛选"}
+		}
+		marks := make([]string, len(f.TagIDs))
+		for i, id := range f.TagIDs {
+			marks[i] = "?"
+			args = append(args, id)
+		}
+		clauses = append(clauses, fmt.Sprintf(s.TagClause, strings.Join(marks, ",")))
+	}
+
+	in := map[string][]interface{}{}
+	for field, vals := range f.In {
+		in[field] = vals
+	}
+	if len(f.AreaIDs) > 0 {
+		for _, id := range f.AreaIDs {
+			in["area_id"] = append(in["area_id"], id)
+		}
+	}
+
+	for _, field := range sortedKeys(in) {
+		col, err := lookup(s, field)
+		if err != nil {
+			return "", nil, err
+		}
+		vals := in[field]
+		if len(vals) == 0 {
+			continue
+		}
+		placeholders := make([]string, len(vals))
+		for i, v := range vals {
+			converted, err := col.Convert(field, bound(v))
+			if err != nil {
+				return "", nil, err
+			}
+			placeholders[i] = "?"
+			args = append(args, converted)
+		}
+		clauses = append(clauses, fmt.Sprintf("%s IN (%s)", col.Column, strings.Join(placeholders, ",")))
+	}
+
+	rangeFields := make([]string, 0, len(f.Ranges))
+	for field := range f.Ranges {
+		rangeFields = append(rangeFields, field)
+	}
+	sort.Strings(rangeFields)
+	for _, field := range rangeFields {
+		col, err := lookup(s, field)
+		if err != nil {
+			return "", nil, err
+		}
+		if col.Kind == search.KindText {
+			return "", nil, &search.Error{Term: field, Msg: "文本字段不支持范围"}
+		}
+		r := f.Ranges[field]
+		if v := bound(r.Min); v != "" {
+			converted, err := col.Convert(field, v)
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, col.Column+" >= ?")
+			args = append(args, converted)
+		}
+		if v := bound(r.Max); v != "" {
+			converted, err := col.Convert(field, v)
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, col.Column+" <= ?")
+			args = append(args, converted)
+		}
+	}
+
+	for _, field := range f.Null {
+		col, err := lookup(s, field)
+		if err != nil {
+			return "", nil, err
+		}
+		if col.Kind == search.KindText {
+			clauses = append(clauses, fmt.Sprintf("(%s IS NULL OR %s = '')", col.Column, col.Column))
+		} else {
+			clauses = append(clauses, col.Column+" IS NULL")
+		}
+	}
+	for _, field := range f.NotNull {
+		col, err := lookup(s, field)
+		if err != nil {
+			return "", nil, err
+		}
+		if col.Kind == search.KindText {
+			clauses = append(clauses, fmt.Sprintf("(%s IS NOT NULL AND %s <> '')", col.Column, col.Column))
+		} else {
+			clauses = append(clauses, col.Column+" IS NOT NULL")
+		}
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// Where 在已有 WHERE 子句后追加筛选条件
+func (f *Filter) Where(s *search.Schema, whereClause string, args []interface{}) (string, []interface{}, error) {
+	clause, filterArgs, err := f.Compile(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if clause != "" {
+		whereClause += " AND " + clause
+		args = append(args, filterArgs...)
+	}
+	return whereClause, args, nil
+}
+
+func lookup(s *search.Schema, field string) (search.Field, error) {
+	col, ok := s.Fields[strings.ToLower(field)]
+	if !ok {
+		return search.Field{}, &search.Error{Term: field, Msg: "未知的筛选字段"}
+	}
+	if !s.Allowed(col.Column) {
+		return search.Field{}, &search.Error{Term: field, Msg: "没有权限按该字段筛选"}
+	}
+	return col, nil
+}
+
+// bound 将 JSON 数字或字符串统一为字符串
+func bound(v interface{}) string {
+	switch b := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(b)
+	case float64:
+		return strconv.FormatFloat(b, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string][]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"sorting-system/filter"
 	"sorting-system/models"
 	"strconv"
 
@@ -13,6 +14,9 @@ func CreateArrival(c *gin.Context) {
 
 	var arrival models.Arrival
 	if err := c.ShouldBindJSON(&arrival); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
 		return
 	}
@@ -20,6 +24,9 @@ func CreateArrival(c *gin.Context) {
 	arrival.UserID = userID
 
 	if err := models.CreateArrival(&arrival); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败: " + err.Error()})
 		return
 	}
@@ -41,6 +48,9 @@ func UpdateArrival(c *gin.Context) {
 
 	var arrival models.Arrival
 	if err := c.ShouldBindJSON(&arrival); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
 		return
 	}
@@ -49,6 +59,9 @@ func UpdateArrival(c *gin.Context) {
 	arrival.UserID = userID
 
 	if err := models.UpdateArrival(&arrival); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
 		return
 	}
@@ -78,14 +91,12 @@ func UpdateArrivalField(c *gin.Context) {
 		return
 	}
 
-	// 转换值类型
-	var value interface{} = req.Value
-	if v, ok := req.Value.(string); ok {
-		value = v
-	}
-
-	arrival, err := models.UpdateArrivalField(id, userID, req.Field, value)
+	// 值的类型转换与校验由 models.ArrivalSchema 完成
+	arrival, err := models.UpdateArrivalField(id, userID, req.Field, req.Value)
 	if err != nil {
+		if respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败: " + err.Error()})
 		return
 	}
@@ -123,21 +134,46 @@ func DeleteArrivals(c *gin.Context) {
 func GetArrivalList(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	orderBy := c.DefaultQuery("order_by", "id")
-	orderDir := c.DefaultQuery("order_dir", "DESC")
-	keyword := c.DefaultQuery("keyword", "")
-	startTime := c.DefaultQuery("start_time", "")
-	endTime := c.DefaultQuery("end_time", "")
+	opt := listOptionsFromQuery(c)
+
+	f, err := filter.FromQuery(c.Request.URL.Query(), "arrival_date")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	view, ok := resolveListView(c, userID, models.ViewListArrival)
+	if !ok {
+		return
+	}
+	if view != nil {
+		f = applyView(view, &opt)
+	}
 
-	if page < 1 {
-		page = 1
+	result, err := models.GetArrivalList(userID, opt, f)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": result,
+	})
+}
+
+// SearchArrivals 按 JSON 筛选条件查询到货列表
+func SearchArrivals(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	req, ok := bindListRequest(c)
+	if !ok {
+		return
 	}
 
-	result, err := models.GetArrivalList(userID, page, pageSize, orderBy, orderDir, keyword, startTime, endTime)
+	result, err := models.GetArrivalList(userID, req.options(), req.Filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 
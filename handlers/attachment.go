@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAttachmentList 获取商品附件列表
+func GetAttachmentList(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	product, ok := loadProduct(c, userID)
+	if !ok {
+		return
+	}
+
+	list, err := models.GetAttachmentList(product, c.DefaultQuery("kind", ""))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// CreateAttachment 为商品添加附件，url 来自 /api/upload
+func CreateAttachment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	product, ok := loadProduct(c, userID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Kind    string `json:"kind"`
+		URL     string `json:"url" binding:"required"`
+		Caption string `json:"caption"`
+		Primary bool   `json:"primary"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = models.AttachmentKindProduct
+	}
+	if !models.ValidAttachmentKinds[req.Kind] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的附件类型"})
+		return
+	}
+
+	attachment := &models.Attachment{
+		ProductID: product.ID,
+		UserID:    userID,
+		Kind:      req.Kind,
+		URL:       req.URL,
+		Caption:   req.Caption,
+	}
+	if err := models.CreateAttachment(attachment, req.Primary); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    attachment,
+		"message": "添加成功",
+	})
+}
+
+// UpdateAttachment 修改附件说明或设为主图
+func UpdateAttachment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	product, ok := loadProduct(c, userID)
+	if !ok {
+		return
+	}
+	attachmentID, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的附件ID"})
+		return
+	}
+
+	var req struct {
+		Caption *string `json:"caption"`
+		Primary bool    `json:"primary"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if req.Caption != nil {
+		if err := models.UpdateAttachmentCaption(attachmentID, product.ID, *req.Caption); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "附件不存在"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+			return
+		}
+	}
+	if req.Primary {
+		if err := models.SetPrimaryAttachment(attachmentID, product.ID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "附件不存在"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "设置主图失败"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "更新成功",
+	})
+}
+
+// DeleteAttachment 删除商品附件
+func DeleteAttachment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	product, ok := loadProduct(c, userID)
+	if !ok {
+		return
+	}
+	attachmentID, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的附件ID"})
+		return
+	}
+
+	if err := models.DeleteAttachment(attachmentID, product.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// ReorderAttachments 按给定顺序重排附件
+func ReorderAttachments(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	product, ok := loadProduct(c, userID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.ReorderAttachments(product.ID, req.IDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "排序失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "排序成功",
+	})
+}
+
+// loadProduct 解析路径中的商品ID并查询商品，失败时已写入响应
+func loadProduct(c *gin.Context, userID int) (*models.Product, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return nil, false
+	}
+
+	product, err := models.GetProductByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	if product == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "产品不存在"})
+		return nil, false
+	}
+	return product, true
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBrandList 品牌目录
+func GetBrandList(c *gin.Context) {
+	list, err := models.GetBrandList()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// SuggestBrands 品牌输入联想，q 匹配品牌名称或别名
+func SuggestBrands(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	list, err := models.SuggestBrands(c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// CreateBrand 创建品牌
+func CreateBrand(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var brand models.Brand
+	if err := c.ShouldBindJSON(&brand); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.CreateBrand(&brand); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "创建失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    brand,
+		"message": "创建成功",
+	})
+}
+
+// UpdateBrand 修改品牌名称、别名、默认币种与供应商
+func UpdateBrand(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var brand models.Brand
+	if err := c.ShouldBindJSON(&brand); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	brand.ID = id
+
+	if err := models.UpdateBrand(&brand); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "更新失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    brand,
+		"message": "更新成功",
+	})
+}
+
+// DeleteBrand 删除品牌
+func DeleteBrand(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.DeleteBrand(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// GetBrandVariants 商品与到货记录中出现的品牌写法及匹配的目录品牌
+func GetBrandVariants(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	list, err := models.GetBrandVariants()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// MergeBrands 将品牌和品牌写法合并到目标品牌，并改写商品与到货记录中的品牌文字
+func MergeBrands(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req struct {
+		TargetID  int      `json:"target_id" binding:"required"`
+		SourceIDs []int    `json:"source_ids"`
+		Variants  []string `json:"variants"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	result, err := models.MergeBrands(req.TargetID, req.SourceIDs, req.Variants)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "品牌不存在"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "合并失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    result,
+		"message": "合并成功",
+	})
+}
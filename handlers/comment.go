@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkCommentTarget 校验评论对象存在且可访问，失败时已写入响应
+func checkCommentTarget(c *gin.Context, userID int, targetType string, targetID int) bool {
+	var exists bool
+	switch targetType {
+	case models.CommentTargetProduct:
+		p, err := models.GetProductByID(targetID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+			return false
+		}
+		exists = p != nil
+	case models.CommentTargetArrival:
+		a, err := models.GetArrivalByID(targetID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+			return false
+		}
+		exists = a != nil
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的评论对象"})
+		return false
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return false
+	}
+	return true
+}
+
+// GetCommentList 获取商品或到货记录的评论线程
+func GetCommentList(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	targetType := c.DefaultQuery("target_type", models.CommentTargetProduct)
+	targetID, err := strconv.Atoi(c.DefaultQuery("target_id", ""))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if !checkCommentTarget(c, userID, targetType, targetID) {
+		return
+	}
+
+	threads, err := models.GetCommentThreads(targetType, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": threads,
+	})
+}
+
+// CreateComment 发表评论，内容中的 @用户名 会通知对应用户，图片先通过 /api/upload 上传
+func CreateComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var comment models.Comment
+	if err := c.ShouldBindJSON(&comment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(comment.Content) == "" && len(comment.Images) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "评论内容不能为空"})
+		return
+	}
+
+	if !checkCommentTarget(c, userID, comment.TargetType, comment.TargetID) {
+		return
+	}
+
+	comment.UserID = userID
+
+	if err := models.CreateComment(&comment); err != nil {
+		if errors.Is(err, models.ErrParentNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "评论失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    comment,
+		"message": "评论成功",
+	})
+}
+
+// DeleteComment 删除自己的评论
+func DeleteComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.DeleteComment(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// GetUnreadMentions 获取提及我的未读评论
+func GetUnreadMentions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	list, err := models.GetUnreadMentions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// MarkMentionsRead 将提及标记为已读，不传 comment_ids 时全部标记
+func MarkMentionsRead(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		CommentIDs []int `json:"comment_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.MarkMentionsRead(userID, req.CommentIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "更新成功",
+	})
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sorting-system/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDuplicateClusters 扫描疑似重复的商品分组，未传 start_time 和 end_time 时默认扫描本月
+func GetDuplicateClusters(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	startTime := c.DefaultQuery("start_time", "")
+	endTime := c.DefaultQuery("end_time", "")
+	if startTime == "" && endTime == "" {
+		startTime = fmt.Sprintf("%s-01 00:00:00", time.Now().Format("2006-01"))
+	}
+
+	clusters, err := models.ScanDuplicateClusters(userID, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": clusters,
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"sorting-system/config"
+	"sorting-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRateCards 运输线路与运价表
+func GetRateCards(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	defaultRoute := ""
+	if config.GlobalConfig != nil {
+		defaultRoute = config.GlobalConfig.Shipping.DefaultRoute
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"default_route": defaultRoute,
+			"routes":        models.RateCards(),
+		},
+	})
+}
+
+// EstimateFreight 按重量（kg）和长宽高（cm）估算运费，route 为空时使用默认线路
+func EstimateFreight(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req struct {
+		Route  string  `json:"route"`
+		Weight float64 `json:"weight"`
+		Length float64 `json:"length"`
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil ||
+		req.Weight < 0 || req.Length < 0 || req.Width < 0 || req.Height < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	estimate, err := models.EstimateFreight(req.Route, req.Weight, req.Length, req.Width, req.Height)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": estimate,
+	})
+}
+
+// ConfirmShippingFees 确认或取消确认商品运费
+//
+// confirmed 为 true 时保留当前运费不再自动估算；为 false 时恢复按重量和尺寸估算
+func ConfirmShippingFees(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req struct {
+		IDs       []int `json:"ids" binding:"required"`
+		Confirmed bool  `json:"confirmed"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	var err error
+	if req.Confirmed {
+		err = models.ConfirmShippingFees(req.IDs)
+	} else {
+		err = models.ReestimateShippingFees(req.IDs, c.GetInt("user_id"))
+	}
+	if err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "更新成功",
+	})
+}
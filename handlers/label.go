@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sorting-system/barcode"
+	"sorting-system/config"
+	"sorting-system/filter"
+	"sorting-system/models"
+	"sorting-system/pdf"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultLabelTemplates 未配置标签模板时使用
+var defaultLabelTemplates = []config.LabelTemplate{
+	{Name: "a4-3x8", Width: 70, Height: 37, Columns: 3, Rows: 8, MarginTop: 0.5, Code128: true, QR: true},
+	{Name: "thermal-60x40", Width: 60, Height: 40, DPI: 203, Code128: true, QR: true},
+}
+
+// labelTemplates 已配置的标签模板及默认模板名称
+func labelTemplates() ([]config.LabelTemplate, string) {
+	templates, defaultName := defaultLabelTemplates, ""
+	if config.GlobalConfig != nil {
+		if len(config.GlobalConfig.Labels.Templates) > 0 {
+			templates = config.GlobalConfig.Labels.Templates
+		}
+		defaultName = config.GlobalConfig.Labels.DefaultTemplate
+	}
+	if defaultName == "" {
+		defaultName = templates[0].Name
+	}
+	return templates, defaultName
+}
+
+// findLabelTemplate 按名称查找模板，名称为空时使用默认模板
+func findLabelTemplate(name string) (config.LabelTemplate, bool) {
+	templates, defaultName := labelTemplates()
+	if name == "" {
+		name = defaultName
+	}
+	for _, t := range templates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return config.LabelTemplate{}, false
+}
+
+// GetLabelTemplates 标签模板列表
+func GetLabelTemplates(c *gin.Context) {
+	templates, defaultName := labelTemplates()
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"default_template": defaultName,
+			"templates":        templates,
+		},
+	})
+}
+
+// PrintLabels 打印分拣标签，每张包含客户、区域、尺码和商品ID的条码与二维码
+//
+// ids=1,2,3 指定商品，也可使用列表筛选参数，如 area_id=3 打印整个区域；
+// template 为模板名称，format 为 pdf（默认，A4 或单张）或 zpl（热敏打印机）
+func PrintLabels(c *gin.Context) {
+	f, err := filter.FromQuery(c.Request.URL.Query(), "created_at")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	if ids := strings.TrimSpace(c.DefaultQuery("ids", "")); ids != "" {
+		if f.In == nil {
+			f.In = map[string][]interface{}{}
+		}
+		for _, s := range strings.Split(ids, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID: " + s})
+				return
+			}
+			f.In["id"] = append(f.In["id"], id)
+		}
+	}
+	if f.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择商品或区域"})
+		return
+	}
+
+	tpl, ok := findLabelTemplate(c.DefaultQuery("template", ""))
+	if !ok || tpl.Width <= 0 || tpl.Height <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "标签模板不存在"})
+		return
+	}
+
+	whereClause, args, err := models.ProductWhere(f, c.GetInt("user_id"))
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	labels, err := models.GetLabels(whereClause, args)
+	if err != nil {
+		if errors.Is(err, models.ErrTooManyLabels) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondQueryError(c, err)
+		return
+	}
+	if len(labels) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "没有符合条件的商品"})
+		return
+	}
+
+	switch c.DefaultQuery("format", "pdf") {
+	case "zpl":
+		c.Header("Content-Disposition", "attachment; filename=labels.zpl")
+		c.Data(http.StatusOK, "application/zpl; charset=utf-8", renderLabelsZPL(labels, tpl))
+	default:
+		c.Header("Content-Disposition", "inline; filename=labels.pdf")
+		c.Data(http.StatusOK, "application/pdf", renderLabelsPDF(labels, tpl))
+	}
+}
+
+// renderLabelsPDF 按模板排版标签；模板有行列时排在 A4 上，否则每页一张
+func renderLabelsPDF(labels []*models.Label, tpl config.LabelTemplate) []byte {
+	w, h := pdf.MmToPt(tpl.Width), pdf.MmToPt(tpl.Height)
+	sheet := tpl.Columns > 0 && tpl.Rows > 0
+
+	var doc *pdf.Document
+	if sheet {
+		doc = pdf.New(pdf.A4Width, pdf.A4Height)
+	} else {
+		doc = pdf.New(w, h)
+	}
+
+	perPage := 1
+	if sheet {
+		perPage = tpl.Columns * tpl.Rows
+	}
+	for i, l := range labels {
+		if i%perPage == 0 {
+			doc.AddPage()
+		}
+		x, y := 0.0, 0.0
+		if sheet {
+			n := i % perPage
+			x = pdf.MmToPt(tpl.MarginLeft + float64(n%tpl.Columns)*(tpl.Width+tpl.GapX))
+			y = pdf.MmToPt(tpl.MarginTop + float64(n/tpl.Columns)*(tpl.Height+tpl.GapY))
+		}
+		drawLabel(doc, l, tpl, x, y, w, h)
+	}
+	return doc.Bytes()
+}
+
+// drawLabel 绘制单张标签：左侧文字与条码，右侧二维码
+func drawLabel(doc *pdf.Document, l *models.Label, tpl config.LabelTemplate, x, y, w, h float64) {
+	pad := pdf.MmToPt(2.5)
+
+	textWidth := w - 2*pad
+	if tpl.QR {
+		if qr, err := barcode.QR(l.Code()); err == nil {
+			side := math.Min(h-2*pad, w*0.38)
+			drawQR(doc, qr, x+w-pad-side, y+pad, side)
+			textWidth -= side + pad
+		}
+	}
+
+	ty := y + pad
+	doc.Text(x+pad, ty, 14, pdf.Truncate(l.CustomerName, 14, textWidth))
+	ty += 18
+	if l.AreaName != "" {
+		doc.Text(x+pad, ty, 10, pdf.Truncate(l.AreaName, 10, textWidth))
+		ty += 13
+	}
+	detail := strings.TrimSpace(l.Size + " " + l.Brand)
+	doc.Text(x+pad, ty, 8, pdf.Truncate(detail, 8, textWidth))
+	ty += 11
+	doc.Text(x+pad, ty, 8, "#"+l.Code())
+	ty += 11
+
+	if tpl.Code128 {
+		if bars, err := barcode.Code128(l.Code()); err == nil {
+			barHeight := y + h - pad - ty
+			if barHeight > 6 {
+				drawCode128(doc, bars, x+pad, ty, math.Min(textWidth, float64(len(bars))*1.5), barHeight)
+			}
+		}
+	}
+}
+
+// drawCode128 在指定宽度内绘制条码，相邻的条合并为一个矩形
+func drawCode128(doc *pdf.Document, modules []bool, x, y, w, h float64) {
+	unit := w / float64(len(modules))
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(modules) && modules[i] {
+			i++
+		}
+		doc.FillRect(x+float64(start)*unit, y, float64(i-start)*unit, h)
+	}
+}
+
+// drawQR 绘制边长为 size 的二维码，逐行合并相邻的深色模块
+func drawQR(doc *pdf.Document, modules [][]bool, x, y, size float64) {
+	unit := size / float64(len(modules))
+	for r, row := range modules {
+		for c := 0; c < len(row); {
+			if !row[c] {
+				c++
+				continue
+			}
+			start := c
+			for c < len(row) && row[c] {
+				c++
+			}
+			doc.FillRect(x+float64(start)*unit, y+float64(r)*unit, float64(c-start)*unit, unit)
+		}
+	}
+}
+
+// zplField 去掉 ZPL 控制字符，避免破坏指令
+func zplField(s string) string {
+	return strings.NewReplacer("^", " ", "~", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// renderLabelsZPL 生成热敏打印机的 ZPL 指令，条码与二维码由打印机生成
+func renderLabelsZPL(labels []*models.Label, tpl config.LabelTemplate) []byte {
+	dpi := tpl.DPI
+	if dpi <= 0 {
+		dpi = 203
+	}
+	dots := func(mm float64) int {
+		return int(math.Round(mm * float64(dpi) / 25.4))
+	}
+	font := func(size int) string {
+		if tpl.Font != "" {
+			return fmt.Sprintf("^A@N,%d,%d,%s", size, size, tpl.Font)
+		}
+		return fmt.Sprintf("^A0N,%d,%d", size, size)
+	}
+
+	width, height, pad := dots(tpl.Width), dots(tpl.Height), dots(2.5)
+	qrMag, qrSide := 0, 0
+	if tpl.QR {
+		// 放大倍数按版本1（21个模块加静区约25）估算，与 PDF 一样最多占标签宽度的38%
+		qrMag = max(1, min(10, min(height-2*pad, width*38/100)/25))
+		qrSide = qrMag * 25
+	}
+	textWidth := width - 2*pad - qrSide
+
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString("^XA^CI28\n")
+		fmt.Fprintf(&b, "^PW%d^LL%d\n", width, height)
+
+		// ^FB 限制为单行，超出文字区域的部分不打印
+		text := func(y, size int, s string) {
+			fmt.Fprintf(&b, "^FO%d,%d%s^FB%d,1,0,L,0^FD%s^FS\n", pad, y, font(size), textWidth, zplField(s))
+		}
+		y := pad
+		text(y, dots(4.5), l.CustomerName)
+		y += dots(6)
+		if l.AreaName != "" {
+			text(y, dots(3.2), l.AreaName)
+			y += dots(4.2)
+		}
+		text(y, dots(2.6), strings.TrimSpace(l.Size+" "+l.Brand))
+		y += dots(3.6)
+		text(y, dots(2.6), "#"+l.Code())
+		y += dots(3.6)
+
+		if tpl.Code128 && height-pad-y > dots(3) {
+			fmt.Fprintf(&b, "^FO%d,%d^BY2^BCN,%d,N,N,N^FD%s^FS\n", pad, y, height-pad-y, l.Code())
+		}
+		if tpl.QR {
+			fmt.Fprintf(&b, "^FO%d,%d^BQN,2,%d^FDMA,%s^FS\n", width-pad-qrSide, pad, qrMag, l.Code())
+		}
+		b.WriteString("^XZ\n")
+	}
+	return []byte(b.String())
+}
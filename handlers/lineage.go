@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondLineageError 拆分合并失败时写入响应
+func respondLineageError(c *gin.Context, action string, err error) {
+	if respondPeriodClosed(c, err) || respondValidation(c, err) {
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "产品不存在"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": action + "失败: " + err.Error()})
+}
+
+// SplitProduct 将一行商品按件数或尺码拆分为多行
+func SplitProduct(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var req struct {
+		By    string             `json:"by"`
+		Parts []models.SplitPart `json:"parts"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	list, err := models.SplitProduct(id, userID, req.By, req.Parts)
+	if err != nil {
+		respondLineageError(c, "拆分", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    list,
+		"message": "拆分成功",
+	})
+}
+
+// MergeProducts 将同一客户的多行商品合并到 keep_id
+func MergeProducts(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		KeepID int   `json:"keep_id" binding:"required"`
+		IDs    []int `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if userID != 1 {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    -1,
+			"message": "你没有权限合并商品",
+		})
+		return
+	}
+
+	product, err := models.MergeProducts(req.KeepID, req.IDs, userID)
+	if err != nil {
+		respondLineageError(c, "合并", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    product,
+		"message": "合并成功",
+	})
+}
+
+// GetProductHistory 商品的拆分与合并记录
+func GetProductHistory(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	list, err := models.GetProductHistory(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
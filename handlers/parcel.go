@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadParcel 根据路径参数获取包裹，失败时已写入响应
+func loadParcel(c *gin.Context) (*models.Parcel, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return nil, false
+	}
+
+	parcel, err := models.GetParcelByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	if parcel == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "包裹不存在"})
+		return nil, false
+	}
+	return parcel, true
+}
+
+// GetParcelList 获取发货包裹列表
+func GetParcelList(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	status := c.DefaultQuery("status", "")
+	if status != "" && !models.ValidParcelStatuses[status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的包裹状态"})
+		return
+	}
+
+	list, err := models.GetParcelList(status, c.DefaultQuery("recipient", ""))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// TrackParcel 按快递单号查找包裹及其商品
+func TrackParcel(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	trackingNo := c.Query("tracking_no")
+	if trackingNo == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写快递单号"})
+		return
+	}
+
+	list, err := models.FindParcelsByTracking(trackingNo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	message := "查询成功"
+	if len(list) == 0 {
+		message = "未找到快递单号对应的包裹"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    list,
+		"message": message,
+	})
+}
+
+// GetParcel 获取包裹及其商品
+func GetParcel(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	parcel, ok := loadParcel(c)
+	if !ok {
+		return
+	}
+
+	items, err := models.GetParcelItems(parcel.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	parcel.Items = items
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": parcel,
+	})
+}
+
+// CreateParcel 创建包裹，可同时装入 product_ids 中的商品
+func CreateParcel(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req struct {
+		models.Parcel
+		ProductIDs []int `json:"product_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	parcel := req.Parcel
+	parcel.UserID = c.GetInt("user_id")
+
+	if err := models.CreateParcel(&parcel, req.ProductIDs); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败: " + err.Error()})
+		return
+	}
+
+	created, err := models.GetParcelByID(parcel.ID)
+	if err != nil || created == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    created,
+		"message": "创建成功",
+	})
+}
+
+// UpdateParcel 更新包裹信息与状态
+func UpdateParcel(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	old, ok := loadParcel(c)
+	if !ok {
+		return
+	}
+
+	var parcel models.Parcel
+	if err := c.ShouldBindJSON(&parcel); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	parcel.ID = old.ID
+	parcel.UserID = old.UserID
+
+	if err := models.UpdateParcel(&parcel); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    parcel,
+		"message": "更新成功",
+	})
+}
+
+// DeleteParcel 删除包裹
+func DeleteParcel(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	parcel, ok := loadParcel(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteParcel(parcel.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// AddParcelItems 将商品装入包裹
+func AddParcelItems(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	parcel, ok := loadParcel(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ProductIDs []int `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.AddParcelItems(parcel.ID, req.ProductIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "添加成功",
+	})
+}
+
+// RemoveParcelItems 将商品移出包裹
+func RemoveParcelItems(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	parcel, ok := loadParcel(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ProductIDs []int `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.RemoveParcelItems(parcel.ID, req.ProductIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "移除成功",
+	})
+}
+
+// GetProductParcel 获取商品所在的包裹，未装入包裹时 data 为 null
+func GetProductParcel(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	parcel, err := models.GetProductParcel(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": parcel,
+	})
+}
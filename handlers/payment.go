@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPaymentList 收款记录，可按 customer_name 或 product_id 过滤
+func GetPaymentList(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	productID, _ := strconv.Atoi(c.DefaultQuery("product_id", "0"))
+	list, err := models.GetPaymentList(strings.TrimSpace(c.DefaultQuery("customer_name", "")), productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// GetCustomerBalance 客户应收余额
+func GetCustomerBalance(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	customerName := strings.TrimSpace(c.DefaultQuery("customer_name", ""))
+	if customerName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定客户"})
+		return
+	}
+
+	balance, err := models.GetCustomerBalance(customerName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": balance,
+	})
+}
+
+// bindPayment 解析收款请求体
+func bindPayment(c *gin.Context) (*models.Payment, bool) {
+	var payment models.Payment
+	if err := c.ShouldBindJSON(&payment); err != nil || payment.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return nil, false
+	}
+	if payment.Kind != "" && !models.ValidPaymentKinds[payment.Kind] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的收款类型"})
+		return nil, false
+	}
+	payment.UserID = c.GetInt("user_id")
+	return &payment, true
+}
+
+// CreatePayment 登记收款
+func CreatePayment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	payment, ok := bindPayment(c)
+	if !ok {
+		return
+	}
+
+	if err := models.CreatePayment(payment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "登记失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    payment,
+		"message": "登记成功",
+	})
+}
+
+// UpdatePayment 修改收款
+func UpdatePayment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	payment, ok := bindPayment(c)
+	if !ok {
+		return
+	}
+	payment.ID = id
+
+	if err := models.UpdatePayment(payment); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "收款记录不存在"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "更新失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    payment,
+		"message": "更新成功",
+	})
+}
+
+// DeletePayment 删除收款
+func DeletePayment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.DeletePayment(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"sorting-system/models"
+	"sorting-system/pdf"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPickListList 最近的拣货单及扫码进度
+func GetPickListList(c *gin.Context) {
+	list, err := models.GetPickListList(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// CreatePickList 生成拣货单，参数为 arrival_id 或到货日期范围 start_time、end_time
+func CreatePickList(c *gin.Context) {
+	var l models.PickList
+	if err := c.ShouldBindJSON(&l); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	l.Name = strings.TrimSpace(l.Name)
+	l.UserID = c.GetInt("user_id")
+
+	if err := models.CreatePickList(&l); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "生成失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    l,
+		"message": "生成成功",
+	})
+}
+
+// GetPickList 拣货单详情，format 为 json（默认）、pdf 或 csv
+func GetPickList(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	l, err := models.GetPickList(id, c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	if l == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "拣货单不存在"})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=picklist-%d.pdf", l.ID))
+		c.Data(http.StatusOK, "application/pdf", renderPickListPDF(l))
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=picklist-%d.csv", l.ID))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", renderPickListCSV(l))
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"code": 0,
+			"data": l,
+		})
+	}
+}
+
+// DeletePickList 删除拣货单
+func DeletePickList(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.DeletePickList(id, c.GetInt("user_id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "拣货单不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// pickListTitle 拣货单标题与范围说明
+func pickListTitle(l *models.PickList) (string, string) {
+	title := l.Name
+	if title == "" {
+		title = fmt.Sprintf("拣货单 #%d", l.ID)
+	}
+	scope := "到货日期：" + formatPeriod(l.StartTime, l.EndTime)
+	if l.ArrivalID > 0 {
+		scope = fmt.Sprintf("到货记录：#%d", l.ArrivalID)
+	}
+	return title, scope
+}
+
+// renderPickListCSV 导出 CSV，带 BOM 便于 Excel 识别 UTF-8
+func renderPickListCSV(l *models.PickList) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"区域", "商品ID", "客户", "品牌", "尺码", "件数", "图片", "扫码时间"})
+	for _, area := range l.Areas {
+		for _, item := range area.Items {
+			scannedAt := ""
+			if item.ScannedAt != nil {
+				scannedAt = *item.ScannedAt
+			}
+			w.Write([]string{
+				area.AreaName,
+				strconv.Itoa(item.ProductID),
+				item.CustomerName,
+				item.Brand,
+				item.Size,
+				strconv.Itoa(item.Quantity),
+				item.Photo,
+				scannedAt,
+			})
+		}
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// renderPickListPDF 按区域分段排版拣货单，每段列出商品图片、客户与尺码
+func renderPickListPDF(l *models.PickList) []byte {
+	const (
+		margin    = 40.0
+		rowHeight = 56.0
+		thumbSize = 48.0
+		fontSize  = 10.0
+	)
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	bottom := pdf.A4Height - margin - 30
+	title, scope := pickListTitle(l)
+
+	// 列：勾选、图片、ID、客户、品牌、尺码、件数、状态
+	cols := []struct {
+		title string
+		x     float64
+		width float64
+	}{
+		{"", margin, 20},
+		{"图片", margin + 20, 56},
+		{"ID", margin + 76, 50},
+		{"客户", margin + 126, 120},
+		{"品牌", margin + 246, 100},
+		{"尺码", margin + 346, 80},
+		{"件数", margin + 426, 40},
+		{"状态", margin + 466, 50},
+	}
+
+	footer := func() {
+		y := pdf.A4Height - margin
+		doc.Line(margin, y-6, pdf.A4Width-margin, y-6, 0.5)
+		doc.Text(margin, y, 8, title)
+		page := fmt.Sprintf("第 %d 页", doc.PageCount())
+		doc.Text(pdf.A4Width-margin-pdf.TextWidth(page, 8), y, 8, page)
+	}
+
+	tableHeader := func(y float64) float64 {
+		for _, col := range cols {
+			doc.Text(col.x, y, fontSize, col.title)
+		}
+		doc.Line(margin, y+16, pdf.A4Width-margin, y+16, 0.8)
+		return y + 22
+	}
+
+	doc.AddPage()
+	y := margin
+	doc.Text(margin, y, 18, title)
+	y += 30
+	doc.Text(margin, y, fontSize, scope)
+	progress := fmt.Sprintf("已扫 %d / %d", l.Scanned, l.Total)
+	doc.Text(pdf.A4Width-margin-pdf.TextWidth(progress, fontSize), y, fontSize, progress)
+	y += 24
+
+	for _, area := range l.Areas {
+		if y+30+22+rowHeight > bottom {
+			footer()
+			doc.AddPage()
+			y = margin
+		}
+		doc.Text(margin, y, 13, fmt.Sprintf("%s（%d 件）", area.AreaName, len(area.Items)))
+		y += 22
+		y = tableHeader(y)
+
+		for _, item := range area.Items {
+			if y+rowHeight > bottom {
+				footer()
+				doc.AddPage()
+				doc.Text(margin, margin, 13, area.AreaName+"（续）")
+				y = tableHeader(margin + 22)
+			}
+
+			doc.Rect(cols[0].x, y+(thumbSize-10)/2, 10, 10, 0.6)
+			drawThumbnail(doc, item.Photo, cols[1].x, y, thumbSize)
+
+			status := "未扫"
+			if item.ScannedAt != nil {
+				status = "已扫"
+			}
+			textY := y + (thumbSize-fontSize)/2
+			values := []string{
+				"",
+				"",
+				strconv.Itoa(item.ProductID),
+				item.CustomerName,
+				item.Brand,
+				item.Size,
+				strconv.Itoa(item.Quantity),
+				status,
+			}
+			for i, v := range values {
+				doc.Text(cols[i].x, textY, fontSize, pdf.Truncate(v, fontSize, cols[i].width-6))
+			}
+			doc.Line(margin, y+rowHeight-4, pdf.A4Width-margin, y+rowHeight-4, 0.3)
+			y += rowHeight
+		}
+		y += 12
+	}
+	footer()
+
+	return doc.Bytes()
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sorting-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPricing 当前的成本与利润计算参数
+func GetPricing(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": models.Pricing(),
+	})
+}
+
+// RecalculateProducts 按当前计算参数重算商品金额，ids 为空时重算所有未结账月份的商品
+func RecalculateProducts(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	// 空请求体表示重算全部
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	n, err := models.RecalculateProducts(req.IDs)
+	if err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重算失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    gin.H{"count": n},
+		"message": "重算完成",
+	})
+}
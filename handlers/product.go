@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
+	"sorting-system/filter"
 	"sorting-system/models"
+	"sorting-system/search"
+	"sorting-system/validate"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +22,9 @@ func CreateProduct(c *gin.Context) {
 
 	var product models.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
 		return
 	}
@@ -22,14 +32,24 @@ func CreateProduct(c *gin.Context) {
 	product.UserID = userID
 
 	if err := models.CreateProduct(&product); err != nil {
+		if respondPeriodClosed(c, err) || respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败: " + err.Error()})
 		return
 	}
 
+	// 疑似重复只做提示，不阻止创建
+	warnings, err := models.FindDuplicateWarnings(&product)
+	if err != nil {
+		warnings = []*models.DuplicateWarning{}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"data":    product,
-		"message": "创建成功",
+		"code":     0,
+		"data":     product,
+		"warnings": warnings,
+		"message":  "创建成功",
 	})
 }
 
@@ -43,6 +63,9 @@ func UpdateProduct(c *gin.Context) {
 
 	var product models.Product
 	if err := c.ShouldBindJSON(&product); err != nil {
+		if respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
 		return
 	}
@@ -50,7 +73,10 @@ func UpdateProduct(c *gin.Context) {
 	product.ID = id
 	product.UserID = userID
 
-	if err := models.UpdateProduct(&product); err != nil {
+	if err := models.UpdateProduct(&product, userID); err != nil {
+		if respondPeriodClosed(c, err) || respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
 		return
 	}
@@ -81,7 +107,8 @@ func UpdateProductField(c *gin.Context) {
 	}
 
 	if userID != 1 {
-		if req.Field == "status_note_photo" || req.Field == "photo" || req.Field == "mark" {
+		if req.Field == "status_note_photo" || req.Field == "photo" || req.Field == "mark" ||
+			req.Field == "weight" || req.Field == "length" || req.Field == "width" || req.Field == "height" {
 
 		} else {
 			c.JSON(http.StatusOK, gin.H{
@@ -91,23 +118,12 @@ func UpdateProductField(c *gin.Context) {
 			return
 		}
 	}
-	// 转换数字类型
-	var value interface{} = req.Value
-	if req.Field == "cost_eur" || req.Field == "exchange_rate" ||
-		req.Field == "price_rmb" || req.Field == "shipping_fee" {
-		if v, ok := req.Value.(float64); ok {
-			value = v
-		} else if v, ok := req.Value.(string); ok {
-			if f, err := strconv.ParseFloat(v, 64); err == nil {
-				value = f
-			}
-		}
-	} else if v, ok := req.Value.(string); ok {
-		value = v
-	}
-
-	product, err := models.UpdateProductField(id, userID, req.Field, value)
+	// 值的类型转换与校验由 models.ProductSchema 完成
+	product, err := models.UpdateProductField(id, userID, req.Field, req.Value)
 	if err != nil {
+		if respondPeriodClosed(c, err) || respondValidation(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败: " + err.Error()})
 		return
 	}
@@ -123,15 +139,44 @@ func DeleteProducts(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
 	var req struct {
-		IDs []int `json:"ids" binding:"required"`
+		IDs    []int          `json:"ids"`
+		Filter *filter.Filter `json:"filter"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil || (len(req.IDs) == 0 && req.Filter.IsEmpty()) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
 		return
 	}
 
+	// 未指定ID时按筛选条件批量删除，仅管理员可用
+	if len(req.IDs) == 0 {
+		if userID != 1 {
+			c.JSON(http.StatusOK, gin.H{
+				"code":    -1,
+				"message": "你没有权限按筛选条件批量删除商品",
+			})
+			return
+		}
+		count, err := models.DeleteProductsByFilter(req.Filter, userID)
+		if err != nil {
+			if respondPeriodClosed(c, err) {
+				return
+			}
+			respondQueryError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code":    0,
+			"data":    gin.H{"count": count},
+			"message": "删除成功",
+		})
+		return
+	}
+
 	if err := models.DeleteProducts(req.IDs, userID); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
 		return
 	}
@@ -145,30 +190,119 @@ func DeleteProducts(c *gin.Context) {
 func GetProductList(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	orderBy := c.DefaultQuery("order_by", "id")
-	orderDir := c.DefaultQuery("order_dir", "DESC")
-	keyword := c.DefaultQuery("keyword", "")
-	currentMonth := fmt.Sprintf("%s-01 00:00:00", time.Now().Format("2006-01"))
-	startTime := c.DefaultQuery("start_time", currentMonth)
-	endTime := c.DefaultQuery("end_time", "")
-
-	// 解析区域ID
-	var areaID *int
-	if areaIDStr := c.DefaultQuery("area_id", ""); areaIDStr != "" {
-		if id, err := strconv.Atoi(areaIDStr); err == nil {
-			areaID = &id
+	opt := listOptionsFromQuery(c)
+
+	f, err := filter.FromQuery(c.Request.URL.Query(), "created_at")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	// 指定 view_id 或未显式传参时套用视图，否则未传 start_time 时默认查询本月
+	view, ok := resolveListView(c, userID, models.ViewListProduct)
+	if !ok {
+		return
+	}
+	if view != nil {
+		f = applyView(view, &opt)
+	} else if _, ok := c.GetQuery("start_time"); !ok && !f.HasRange("created_at") {
+		currentMonth := fmt.Sprintf("%s-01 00:00:00", time.Now().Format("2006-01"))
+		f.SetRange("created_at", currentMonth, "")
+	}
+	// unpaid=1 只看未付清的商品，在套用视图之后加入，与视图的筛选条件同时生效
+	if c.DefaultQuery("unpaid", "") == "1" {
+		if f.In == nil {
+			f.In = map[string][]interface{}{}
 		}
+		f.In["paid"] = []interface{}{0}
+	}
+
+	result, err := models.GetProductList(userID, opt, f)
+	if err != nil {
+		respondQueryError(c, err)
+		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": result,
+	})
+}
+
+// listOptionsFromQuery 解析列表的分页与排序参数
+//
+// 传 cursor 时按游标翻页（取上一页返回的 next_cursor），skip_total=1 时不统计总数
+func listOptionsFromQuery(c *gin.Context) models.ListOptions {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	if page < 1 {
 		page = 1
 	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return models.ListOptions{
+		Page:      page,
+		PageSize:  pageSize,
+		OrderBy:   c.DefaultQuery("order_by", "id"),
+		OrderDir:  c.DefaultQuery("order_dir", "DESC"),
+		Cursor:    c.DefaultQuery("cursor", ""),
+		SkipTotal: c.DefaultQuery("skip_total", "") == "1",
+	}
+}
+
+// listRequest 结构化筛选的列表请求体
+type listRequest struct {
+	Page      int            `json:"page"`
+	PageSize  int            `json:"page_size"`
+	OrderBy   string         `json:"order_by"`
+	OrderDir  string         `json:"order_dir"`
+	Cursor    string         `json:"cursor"`
+	SkipTotal bool           `json:"skip_total"`
+	Filter    *filter.Filter `json:"filter"`
+}
+
+func (r *listRequest) options() models.ListOptions {
+	return models.ListOptions{
+		Page:      r.Page,
+		PageSize:  r.PageSize,
+		OrderBy:   r.OrderBy,
+		OrderDir:  r.OrderDir,
+		Cursor:    r.Cursor,
+		SkipTotal: r.SkipTotal,
+	}
+}
 
-	result, err := models.GetProductList(userID, page, pageSize, orderBy, orderDir, keyword, startTime, endTime, areaID)
+// bindListRequest 解析列表请求体并补全分页默认值
+func bindListRequest(c *gin.Context) (*listRequest, bool) {
+	req := &listRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		return nil, false
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.Filter == nil {
+		req.Filter = &filter.Filter{}
+	}
+	return req, true
+}
+
+// SearchProducts 按 JSON 筛选条件查询商品列表
+func SearchProducts(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	req, ok := bindListRequest(c)
+	if !ok {
+		return
+	}
+
+	result, err := models.GetProductList(userID, req.options(), req.Filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+		respondQueryError(c, err)
 		return
 	}
 
@@ -178,6 +312,98 @@ func GetProductList(c *gin.Context) {
 	})
 }
 
+// GetGroupedSummary 按维度分组汇总商品，用于透视表
+//
+// group_by 为逗号分隔的一到两个维度（area/brand/customer/status/day/week/month），
+// status 按 pending/arrived/sorted/packed/shipped/delivered 分组，
+// 筛选参数与商品列表相同，未传 start_time 时默认统计本月
+func GetGroupedSummary(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	dims := []string{}
+	for _, dim := range strings.Split(c.DefaultQuery("group_by", ""), ",") {
+		if dim = strings.TrimSpace(dim); dim != "" {
+			dims = append(dims, dim)
+		}
+	}
+	if len(dims) == 0 || len(dims) > 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by 需为1到2个维度"})
+		return
+	}
+	for _, dim := range dims {
+		if !models.IsSummaryDimension(dim) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的分组维度: " + dim})
+			return
+		}
+	}
+
+	f, err := filter.FromQuery(c.Request.URL.Query(), "created_at")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	if _, ok := c.GetQuery("start_time"); !ok && !f.HasRange("created_at") {
+		currentMonth := fmt.Sprintf("%s-01 00:00:00", time.Now().Format("2006-01"))
+		f.SetRange("created_at", currentMonth, "")
+	}
+
+	whereClause, args, err := models.ProductWhere(f, userID)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	result, err := models.GetGroupedSummary(userID, dims, whereClause, args)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": result,
+	})
+}
+
+// respondQueryError 搜索、筛选语法或游标错误返回400，其余返回500
+func respondQueryError(c *gin.Context, err error) {
+	var syntaxErr *search.Error
+	if errors.As(err, &syntaxErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "筛选条件错误: " + syntaxErr.Error()})
+		return
+	}
+	if errors.Is(err, models.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+}
+
+// respondValidation 错误为参数校验失败或 JSON 类型不符时返回 422 及各字段的错误，并返回 true
+func respondValidation(c *gin.Context, err error) bool {
+	var fields validate.Errors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &fields):
+	case errors.As(err, &typeErr):
+		expected := "文本"
+		switch typeErr.Type.Kind() {
+		case reflect.Int, reflect.Int64, reflect.Float64, reflect.Ptr:
+			expected = "数字"
+		case reflect.Bool:
+			expected = "布尔值"
+		}
+		fields = validate.Errors{{Field: typeErr.Field, Code: validate.CodeInvalidType, Message: typeErr.Field + "应为" + expected}}
+	default:
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  "参数校验失败",
+		"fields": fields,
+	})
+	return true
+}
+
 func GetProduct(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	id, err := strconv.Atoi(c.Param("id"))
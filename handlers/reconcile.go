@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetArrivalItems 获取到货记录关联的商品
+func GetArrivalItems(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	arrival, ok := loadArrival(c, userID)
+	if !ok {
+		return
+	}
+
+	items, err := models.GetArrivalItems(arrival.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": items,
+	})
+}
+
+// AddArrivalItems 为到货记录关联商品
+func AddArrivalItems(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	arrival, ok := loadArrival(c, userID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ProductIDs []int `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.AddArrivalItems(arrival.ID, userID, req.ProductIDs); err != nil {
+		if errors.Is(err, models.ErrProductNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关联失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "关联成功",
+	})
+}
+
+// RemoveArrivalItems 取消到货记录与商品的关联
+func RemoveArrivalItems(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	arrival, ok := loadArrival(c, userID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ProductIDs []int `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.RemoveArrivalItems(arrival.ID, req.ProductIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消关联失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "取消关联成功",
+	})
+}
+
+// GetArrivalCandidates 按品牌和日期窗口推荐到货记录的候选商品
+func GetArrivalCandidates(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	arrival, ok := loadArrival(c, userID)
+	if !ok {
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	list, err := models.MatchArrivalCandidates(arrival, userID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// GetReconcileReport 获取到货对账报告
+func GetReconcileReport(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	startTime := c.DefaultQuery("start_time", "")
+	endTime := c.DefaultQuery("end_time", "")
+
+	report, err := models.GetReconcileReport(userID, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": report,
+	})
+}
+
+// loadArrival 解析路径中的到货记录ID并校验归属，失败时已写入响应
+func loadArrival(c *gin.Context, userID int) (*models.Arrival, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return nil, false
+	}
+
+	arrival, err := models.GetArrivalByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	if arrival == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+		return nil, false
+	}
+	return arrival, true
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAdmin 非管理员时写入无权限响应并返回 false
+func requireAdmin(c *gin.Context) bool {
+	if c.GetInt("user_id") != 1 {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    -1,
+			"message": "你没有权限查看或修改财务数据",
+		})
+		return false
+	}
+	return true
+}
+
+// respondPeriodClosed 错误为月份已结账时写入响应并返回 true
+func respondPeriodClosed(c *gin.Context, err error) bool {
+	if !errors.Is(err, models.ErrPeriodClosed) {
+		return false
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    -1,
+		"message": err.Error(),
+	})
+	return true
+}
+
+// GetProfitLoss 月度损益表，默认最近12个月
+func GetProfitLoss(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	now := time.Now()
+	startMonth := c.DefaultQuery("start_month", now.AddDate(0, -11, 0).Format("2006-01"))
+	endMonth := c.DefaultQuery("end_month", now.Format("2006-01"))
+
+	list, err := models.GetProfitLoss(startMonth, endMonth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// GetClosedPeriods 获取已结账月份
+func GetClosedPeriods(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	list, err := models.GetClosedPeriods()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+type periodRequest struct {
+	Month string `json:"month" binding:"required"`
+}
+
+// ClosePeriod 月份结账
+func ClosePeriod(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req periodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if _, err := models.ParseMonth(req.Month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := models.ClosePeriod(req.Month, c.GetInt("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "结账失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "结账成功",
+	})
+}
+
+// ReopenPeriod 取消月份结账
+func ReopenPeriod(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req periodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.ReopenPeriod(req.Month); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "已取消结账",
+	})
+}
+
+// GetExpenseList 获取费用列表，可按 month 过滤
+func GetExpenseList(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	list, err := models.GetExpenseList(c.DefaultQuery("month", ""))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// bindExpense 解析并校验费用请求体
+func bindExpense(c *gin.Context) (*models.Expense, bool) {
+	var expense models.Expense
+	if err := c.ShouldBindJSON(&expense); err != nil || strings.TrimSpace(expense.Category) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return nil, false
+	}
+	if _, err := models.ParseMonth(expense.Month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &expense, true
+}
+
+// CreateExpense 登记费用
+func CreateExpense(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	expense, ok := bindExpense(c)
+	if !ok {
+		return
+	}
+	expense.UserID = c.GetInt("user_id")
+
+	if err := models.CreateExpense(expense); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    expense,
+		"message": "创建成功",
+	})
+}
+
+// UpdateExpense 修改费用
+func UpdateExpense(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	expense, ok := bindExpense(c)
+	if !ok {
+		return
+	}
+	expense.ID = id
+
+	if err := models.UpdateExpense(expense); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "费用不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    expense,
+		"message": "更新成功",
+	})
+}
+
+// DeleteExpense 删除费用
+func DeleteExpense(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.DeleteExpense(id); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
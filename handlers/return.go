@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReturnList 获取商品的退货记录
+func GetReturnList(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	product, ok := loadProduct(c, c.GetInt("user_id"))
+	if !ok {
+		return
+	}
+
+	list, err := models.GetReturnList(product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// bindReturn 解析退货请求体，金额不能为负数
+func bindReturn(c *gin.Context, productID int) (*models.ProductReturn, bool) {
+	var r models.ProductReturn
+	if err := c.ShouldBindJSON(&r); err != nil ||
+		r.RefundToCustomer < 0 || r.RefundFromSupplier < 0 || r.RestockingFee < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return nil, false
+	}
+	r.ProductID = productID
+	r.UserID = c.GetInt("user_id")
+	return &r, true
+}
+
+// CreateReturn 登记退货，商品利润与客户应付金额随之重算
+func CreateReturn(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	product, ok := loadProduct(c, c.GetInt("user_id"))
+	if !ok {
+		return
+	}
+
+	r, ok := bindReturn(c, product.ID)
+	if !ok {
+		return
+	}
+	if err := models.CreateReturn(r); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登记失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    r,
+		"message": "登记成功",
+	})
+}
+
+// UpdateReturn 修改退货记录
+func UpdateReturn(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	product, ok := loadProduct(c, c.GetInt("user_id"))
+	if !ok {
+		return
+	}
+	old, ok := loadReturn(c, product.ID)
+	if !ok {
+		return
+	}
+
+	r, ok := bindReturn(c, product.ID)
+	if !ok {
+		return
+	}
+	r.ID = old.ID
+	r.UserID = old.UserID
+	if r.ReturnedAt == "" {
+		r.ReturnedAt = old.ReturnedAt
+	}
+
+	if err := models.UpdateReturn(r); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "退货记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    r,
+		"message": "更新成功",
+	})
+}
+
+// DeleteReturn 删除退货记录
+func DeleteReturn(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	product, ok := loadProduct(c, c.GetInt("user_id"))
+	if !ok {
+		return
+	}
+	r, ok := loadReturn(c, product.ID)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteReturn(r.ID, product.ID); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "退货记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// loadReturn 根据路径参数 return_id 读取商品下的退货记录，失败时写入响应
+func loadReturn(c *gin.Context, productID int) (*models.ProductReturn, bool) {
+	id, err := strconv.Atoi(c.Param("return_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return nil, false
+	}
+
+	r, err := models.GetReturnByID(id, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	if r == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "退货记录不存在"})
+		return nil, false
+	}
+	return r, true
+}
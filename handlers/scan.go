@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Scan 扫码枪提交条码，返回商品应放的区域与客户
+//
+// code 可以是标签上的商品条码（如 P123）、到货箱号或运单号；mark_sorted 为 true 时将唯一匹配的商品标记为已分拣
+func Scan(c *gin.Context) {
+	var req struct {
+		Code       string `json:"code" binding:"required"`
+		SessionID  int    `json:"session_id"`
+		MarkSorted bool   `json:"mark_sorted"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	result, err := models.Scan(req.Code, c.GetInt("user_id"), req.SessionID, req.MarkSorted)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "分拣会话不存在"})
+		case errors.Is(err, models.ErrSessionClosed):
+			c.JSON(http.StatusOK, gin.H{"code": -1, "message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "扫码失败: " + err.Error()})
+		}
+		return
+	}
+
+	message := "扫码成功"
+	if len(result.Products) == 0 {
+		message = "未找到条码对应的商品"
+	} else if len(result.Products) > 1 {
+		message = "条码对应多件商品，请扫描商品标签"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    result,
+		"message": message,
+	})
+}
+
+// GetScanSessionList 最近的分拣会话
+func GetScanSessionList(c *gin.Context) {
+	list, err := models.GetScanSessionList(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// CreateScanSession 开始分拣会话，可指定 arrival_id 或 shipment_id 作为应扫范围
+func CreateScanSession(c *gin.Context) {
+	var session models.ScanSession
+	if err := c.ShouldBindJSON(&session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	session.Name = strings.TrimSpace(session.Name)
+	session.UserID = c.GetInt("user_id")
+
+	if err := models.CreateScanSession(&session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "创建失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    session,
+		"message": "创建成功",
+	})
+}
+
+// GetScanSession 分拣会话汇总：各区域应扫、已扫、缺少和多出的件数
+func GetScanSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	summary, err := models.GetSessionSummary(id, c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	if summary == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分拣会话不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": summary,
+	})
+}
+
+// CloseScanSession 结束分拣会话
+func CloseScanSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.CloseScanSession(id, c.GetInt("user_id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "分拣会话不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "会话已结束",
+	})
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadShipment 根据路径参数获取运单，失败时已写入响应
+func loadShipment(c *gin.Context) (*models.Shipment, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return nil, false
+	}
+
+	shipment, err := models.GetShipmentByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	if shipment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "运单不存在"})
+		return nil, false
+	}
+	return shipment, true
+}
+
+// respondAllocateError 分摊失败时写入响应
+func respondAllocateError(c *gin.Context, err error) {
+	if respondPeriodClosed(c, err) {
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "运单不存在"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "分摊失败: " + err.Error()})
+}
+
+// GetShipmentList 获取运单列表
+func GetShipmentList(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	list, err := models.GetShipmentList()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// GetShipment 获取运单及其商品
+func GetShipment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	shipment, ok := loadShipment(c)
+	if !ok {
+		return
+	}
+
+	items, err := models.GetShipmentItems(shipment.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	shipment.Items = items
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": shipment,
+	})
+}
+
+// CreateShipment 创建运单
+func CreateShipment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var shipment models.Shipment
+	if err := c.ShouldBindJSON(&shipment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if shipment.Method != "" && !models.ValidAllocateMethods[shipment.Method] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的分摊方式"})
+		return
+	}
+
+	shipment.UserID = c.GetInt("user_id")
+
+	if err := models.CreateShipment(&shipment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    shipment,
+		"message": "创建成功",
+	})
+}
+
+// UpdateShipment 更新运单，已分摊过的运单按新费用重新分摊
+func UpdateShipment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	old, ok := loadShipment(c)
+	if !ok {
+		return
+	}
+
+	var shipment models.Shipment
+	if err := c.ShouldBindJSON(&shipment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if shipment.Method != "" && !models.ValidAllocateMethods[shipment.Method] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的分摊方式"})
+		return
+	}
+
+	shipment.ID = old.ID
+	shipment.UserID = old.UserID
+
+	if err := models.UpdateShipment(&shipment); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	if old.AllocatedAt != nil {
+		items, err := models.AllocateShipment(shipment.ID)
+		if err != nil {
+			respondAllocateError(c, err)
+			return
+		}
+		shipment.Items = items
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    shipment,
+		"message": "更新成功",
+	})
+}
+
+// DeleteShipment 删除运单
+func DeleteShipment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	shipment, ok := loadShipment(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteShipment(shipment.ID); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// AddShipmentItems 将商品加入运单
+func AddShipmentItems(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	shipment, ok := loadShipment(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ProductIDs []int `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	skipped, err := models.AddShipmentItems(shipment.ID, req.ProductIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加失败"})
+		return
+	}
+
+	message := "添加成功"
+	if len(skipped) > 0 {
+		message = fmt.Sprintf("已添加，%d件商品不存在或已在其他运单中", len(skipped))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    gin.H{"skipped": skipped},
+		"message": message,
+	})
+}
+
+// RemoveShipmentItems 将商品移出运单
+func RemoveShipmentItems(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	shipment, ok := loadShipment(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ProductIDs []int `json:"product_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.RemoveShipmentItems(shipment.ID, req.ProductIDs); err != nil {
+		if respondPeriodClosed(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "移除成功",
+	})
+}
+
+// UpdateShipmentItem 设置运单商品的重量和手动比例
+func UpdateShipmentItem(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	shipment, ok := loadShipment(c)
+	if !ok {
+		return
+	}
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的商品ID"})
+		return
+	}
+
+	var item models.ShipmentItem
+	if err := c.ShouldBindJSON(&item); err != nil || item.Weight < 0 || item.Ratio < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	item.ShipmentID = shipment.ID
+	item.ProductID = productID
+
+	if err := models.UpdateShipmentItem(&item); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "运单中没有该商品"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "更新成功",
+	})
+}
+
+// AllocateShipment 分摊运单费用到商品
+func AllocateShipment(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	shipment, ok := loadShipment(c)
+	if !ok {
+		return
+	}
+
+	items, err := models.AllocateShipment(shipment.ID)
+	if err != nil {
+		respondAllocateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    items,
+		"message": "分摊成功",
+	})
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"image"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sorting-system/config"
+	"sorting-system/models"
+	"sorting-system/pdf"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCustomerStatement 客户对账单
+//
+// 参数 customer_name、start_time、end_time；format 为 html（默认，可直接打印）、pdf 或 json
+func GetCustomerStatement(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	customerName := strings.TrimSpace(c.DefaultQuery("customer_name", ""))
+	if customerName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定客户"})
+		return
+	}
+
+	st, err := models.GetCustomerStatement(customerName, c.DefaultQuery("start_time", ""), c.DefaultQuery("end_time", ""))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	cfg := statementConfig()
+	switch c.DefaultQuery("format", "html") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{
+			"code": 0,
+			"data": st,
+		})
+	case "pdf":
+		filename := url.PathEscape(fmt.Sprintf("%s-%s.pdf", cfg.Title, st.CustomerName))
+		c.Header("Content-Disposition", "inline; filename*=UTF-8''"+filename)
+		c.Data(http.StatusOK, "application/pdf", renderStatementPDF(st, cfg))
+	default:
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := statementTemplate.Execute(c.Writer, gin.H{"Config": cfg, "Statement": st}); err != nil {
+			c.String(http.StatusInternalServerError, "render error")
+		}
+	}
+}
+
+// statementConfig 对账单配置，未配置标题时使用默认标题
+func statementConfig() config.StatementConfig {
+	var cfg config.StatementConfig
+	if config.GlobalConfig != nil {
+		cfg = config.GlobalConfig.Statement
+	}
+	if cfg.Title == "" {
+		cfg.Title = "客户对账单"
+	}
+	return cfg
+}
+
+// loadThumbnail 读取上传图片并缩放到不超过 size 像素，读取失败返回 nil
+func loadThumbnail(photo string, size int) image.Image {
+	if photo == "" || config.GlobalConfig == nil {
+		return nil
+	}
+	path := filepath.Join(config.GlobalConfig.Upload.Path, filepath.Base(photo))
+	img, err := imaging.Open(path)
+	if err != nil {
+		return nil
+	}
+	return imaging.Fit(img, size, size, imaging.Lanczos)
+}
+
+// drawThumbnail 在 size×size 的方框内按比例居中绘制商品图片，没有图片时不绘制
+func drawThumbnail(doc *pdf.Document, photo string, x, y, size float64) {
+	img := loadThumbnail(photo, 160)
+	if img == nil {
+		return
+	}
+	b := img.Bounds()
+	w, h := size, size
+	if b.Dx() > b.Dy() {
+		h = size * float64(b.Dy()) / float64(b.Dx())
+	} else {
+		w = size * float64(b.Dx()) / float64(b.Dy())
+	}
+	doc.Image(img, x, y+(size-h)/2, w, h)
+}
+
+// formatPeriod 对账期间的展示文字
+func formatPeriod(start, end string) string {
+	if start == "" && end == "" {
+		return "全部"
+	}
+	return fmt.Sprintf("%s 至 %s", dateOnly(start), dateOnly(end))
+}
+
+func dateOnly(s string) string {
+	if len(s) >= 10 {
+		return s[:10]
+	}
+	return s
+}
+
+// paidText 付款状态，部分付款时显示已付金额
+func paidText(item *models.StatementItem) string {
+	if item.Refunded > 0 && item.Refunded >= item.PriceRMB {
+		return "已退货"
+	}
+	if item.Paid {
+		return "已付"
+	}
+	if item.PaidAmount > 0 {
+		return fmt.Sprintf("已付%.2f", item.PaidAmount)
+	}
+	return "未付"
+}
+
+// renderStatementPDF 按 A4 纵向排版对账单
+func renderStatementPDF(st *models.Statement, cfg config.StatementConfig) []byte {
+	const (
+		margin    = 40.0
+		rowHeight = 56.0
+		thumbSize = 48.0
+		fontSize  = 10.0
+	)
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	bottom := pdf.A4Height - margin - 30
+
+	// 列：图片、日期、品牌、尺码、件数、售价、状态
+	cols := []struct {
+		title string
+		x     float64
+		width float64
+	}{
+		{"图片", margin, 56},
+		{"日期", margin + 56, 70},
+		{"品牌", margin + 126, 140},
+		{"尺码", margin + 266, 100},
+		{"件数", margin + 366, 40},
+		{"售价", margin + 406, 60},
+		{"状态", margin + 466, 50},
+	}
+
+	footer := func() {
+		y := pdf.A4Height - margin
+		doc.Line(margin, y-6, pdf.A4Width-margin, y-6, 0.5)
+		doc.Text(margin, y, 8, pdf.Truncate(cfg.Footer, 8, pdf.A4Width-2*margin-60))
+		page := fmt.Sprintf("第 %d 页", doc.PageCount())
+		doc.Text(pdf.A4Width-margin-pdf.TextWidth(page, 8), y, 8, page)
+	}
+
+	tableHeader := func(y float64) float64 {
+		for _, col := range cols {
+			doc.Text(col.x, y, fontSize, col.title)
+		}
+		doc.Line(margin, y+16, pdf.A4Width-margin, y+16, 0.8)
+		return y + 22
+	}
+
+	doc.AddPage()
+	y := margin
+	if cfg.Header != "" {
+		doc.Text(margin, y, 9, pdf.Truncate(cfg.Header, 9, pdf.A4Width-2*margin))
+		y += 18
+	}
+	doc.Text(margin, y, 18, cfg.Title)
+	y += 30
+	doc.Text(margin, y, fontSize, "客户："+st.CustomerName)
+	doc.Text(margin+260, y, fontSize, "期间："+formatPeriod(st.StartTime, st.EndTime))
+	y += 24
+	y = tableHeader(y)
+
+	for _, item := range st.Items {
+		if y+rowHeight > bottom {
+			footer()
+			doc.AddPage()
+			y = tableHeader(margin)
+		}
+
+		drawThumbnail(doc, item.Photo, cols[0].x, y, thumbSize)
+
+		textY := y + (thumbSize-fontSize)/2
+		values := []string{
+			"",
+			dateOnly(item.CreatedAt),
+			item.Brand,
+			item.Size,
+			fmt.Sprint(item.Quantity),
+			fmt.Sprintf("%.2f", item.PriceRMB),
+			paidText(item),
+		}
+		for i, v := range values {
+			doc.Text(cols[i].x, textY, fontSize, pdf.Truncate(v, fontSize, cols[i].width-6))
+		}
+		doc.Line(margin, y+rowHeight-4, pdf.A4Width-margin, y+rowHeight-4, 0.3)
+		y += rowHeight
+	}
+
+	if y+86 > bottom {
+		footer()
+		doc.AddPage()
+		y = margin
+	}
+	y += 8
+	totals := []string{
+		fmt.Sprintf("合计件数：%d", st.TotalQuantity),
+		fmt.Sprintf("合计金额：%.2f", st.TotalAmount),
+		fmt.Sprintf("退货退款：%.2f", st.RefundAmount),
+		fmt.Sprintf("已付金额：%.2f", st.PaidAmount),
+		fmt.Sprintf("应付余额：%.2f", st.UnpaidAmount),
+	}
+	for _, line := range totals {
+		doc.Text(pdf.A4Width-margin-160, y, 11, line)
+		y += 16
+	}
+	footer()
+
+	return doc.Bytes()
+}
+
+var statementTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date":   dateOnly,
+	"paid":   paidText,
+	"period": formatPeriod,
+	"money":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Config.Title}} - {{.Statement.CustomerName}}</title>
+<style>
+  body { font-family: "PingFang SC", "Microsoft YaHei", sans-serif; font-size: 13px; margin: 24px; color: #303133; }
+  h1 { font-size: 22px; margin: 8px 0 16px; }
+  .header, .footer { color: #606266; font-size: 12px; }
+  .meta { display: flex; justify-content: space-between; margin-bottom: 12px; }
+  table { width: 100%; border-collapse: collapse; }
+  th, td { border-bottom: 1px solid #ebeef5; padding: 6px 8px; text-align: left; vertical-align: middle; }
+  th { border-bottom: 2px solid #909399; }
+  td.num { text-align: right; }
+  img { max-width: 60px; max-height: 60px; }
+  .unpaid { color: #f56c6c; }
+  .totals { margin-top: 16px; text-align: right; line-height: 1.8; }
+  .footer { margin-top: 24px; border-top: 1px solid #dcdfe6; padding-top: 8px; }
+  @media print { body { margin: 0; } thead { display: table-header-group; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+{{with .Config.Header}}<div class="header">{{.}}</div>{{end}}
+<h1>{{.Config.Title}}</h1>
+<div class="meta">
+  <span>客户：{{.Statement.CustomerName}}</span>
+  <span>期间：{{period .Statement.StartTime .Statement.EndTime}}</span>
+</div>
+<table>
+  <thead>
+    <tr><th>图片</th><th>日期</th><th>品牌</th><th>尺码</th><th>件数</th><th>售价</th><th>状态</th></tr>
+  </thead>
+  <tbody>
+  {{range .Statement.Items}}
+    <tr>
+      <td>{{if .Photo}}<img src="{{.Photo}}?w=120" alt="">{{end}}</td>
+      <td>{{date .CreatedAt}}</td>
+      <td>{{.Brand}}</td>
+      <td>{{.Size}}</td>
+      <td class="num">{{.Quantity}}</td>
+      <td class="num">{{money .PriceRMB}}</td>
+      <td{{if not .Paid}} class="unpaid"{{end}}>{{paid .}}</td>
+    </tr>
+  {{end}}
+  </tbody>
+</table>
+<div class="totals">
+  <div>合计件数：{{.Statement.TotalQuantity}}</div>
+  <div>合计金额：{{money .Statement.TotalAmount}}</div>
+  <div>退货退款：{{money .Statement.RefundAmount}}</div>
+  <div>已付金额：{{money .Statement.PaidAmount}}</div>
+  <div>应付余额：<strong>{{money .Statement.UnpaidAmount}}</strong></div>
+</div>
+{{with .Config.Footer}}<div class="footer">{{.}}</div>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Suggest 输入联想，field 为 customer_name、address、brand、size 或 confirm_person，q 为已输入的文字
+func Suggest(c *gin.Context) {
+	field := c.Query("field")
+	if !models.IsSuggestField(field) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的字段: " + field})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	list, err := models.Suggest(field, c.Query("q"), c.GetInt("user_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	// 每次按键都会请求，允许浏览器短时间缓存相同的查询
+	c.Header("Cache-Control", "private, max-age=30")
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// SuggestCustomerAddress 选中客户后返回其最近使用的收件地址，address 为最新一个
+func SuggestCustomerAddress(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("customer_name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定客户"})
+		return
+	}
+
+	addresses, err := models.GetCustomerAddresses(name, c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	address := ""
+	if len(addresses) > 0 {
+		address = addresses[0]
+	}
+	c.Header("Cache-Control", "private, max-age=30")
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": gin.H{
+			"address":   address,
+			"addresses": addresses,
+		},
+	})
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sorting-system/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTag 创建标签
+func CreateTag(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil || strings.TrimSpace(tag.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	tag.UserID = userID
+
+	if err := models.CreateTag(&tag); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    tag,
+		"message": "创建成功",
+	})
+}
+
+// UpdateTag 更新标签
+func UpdateTag(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil || strings.TrimSpace(tag.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	tag.ID = id
+	tag.UserID = userID
+
+	if err := models.UpdateTag(&tag); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    tag,
+		"message": "更新成功",
+	})
+}
+
+// DeleteTag 删除标签
+func DeleteTag(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.DeleteTag(id, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// GetTagList 获取标签列表
+func GetTagList(c *gin.Context) {
+	list, err := models.GetTagList()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+type productTagsRequest struct {
+	ProductIDs []int `json:"product_ids" binding:"required"`
+	TagIDs     []int `json:"tag_ids" binding:"required"`
+}
+
+// AddProductTags 批量为商品添加标签
+func AddProductTags(c *gin.Context) {
+	var req productTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.AddProductTags(req.ProductIDs, req.TagIDs); err != nil {
+		if errors.Is(err, models.ErrTagNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加标签失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "添加成功",
+	})
+}
+
+// RemoveProductTags 批量移除商品的标签
+func RemoveProductTags(c *gin.Context) {
+	var req productTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := models.RemoveProductTags(req.ProductIDs, req.TagIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除标签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "移除成功",
+	})
+}
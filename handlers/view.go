@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sorting-system/filter"
+	"sorting-system/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSavedView 创建列表视图
+func CreateSavedView(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var view models.SavedView
+	if err := c.ShouldBindJSON(&view); err != nil || strings.TrimSpace(view.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	view.UserID = userID
+
+	if err := models.CreateSavedView(&view); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    view,
+		"message": "创建成功",
+	})
+}
+
+// UpdateSavedView 更新列表视图
+func UpdateSavedView(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var view models.SavedView
+	if err := c.ShouldBindJSON(&view); err != nil || strings.TrimSpace(view.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	view.ID = id
+	view.UserID = userID
+
+	if err := models.UpdateSavedView(&view); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "视图不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"data":    view,
+		"message": "更新成功",
+	})
+}
+
+// DeleteSavedView 删除列表视图
+func DeleteSavedView(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := models.DeleteSavedView(id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "视图不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "删除成功",
+	})
+}
+
+// GetSavedView 获取单个列表视图
+func GetSavedView(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	view, err := models.GetSavedViewByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	if view == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "视图不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": view,
+	})
+}
+
+// GetSavedViewList 获取自己的和共享的列表视图
+func GetSavedViewList(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	listType := c.DefaultQuery("list_type", "")
+
+	list, err := models.GetSavedViewList(userID, listType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"data": list,
+	})
+}
+
+// listParamKeys 显式指定列表条件的查询参数，出现任意一个时不套用默认视图
+var listParamKeys = []string{
+	"keyword", "start_time", "end_time", "area_id", "tag_id", "order_by", "order_dir", "page_size", "null", "not_null",
+	"unpaid",
+}
+
+// hasExplicitListParams 请求是否显式指定了筛选或排序
+func hasExplicitListParams(c *gin.Context) bool {
+	values := c.Request.URL.Query()
+	for _, key := range listParamKeys {
+		if _, ok := values[key]; ok {
+			return true
+		}
+	}
+	for key := range values {
+		if strings.HasPrefix(key, "in.") || strings.HasPrefix(key, "min.") || strings.HasPrefix(key, "max.") {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveListView 按 view_id 或默认视图确定要套用的视图，没有时返回 nil；失败时已写入响应
+//
+// view_id 无效、视图不存在或不是该列表的视图时返回错误，不会静默忽略
+func resolveListView(c *gin.Context, userID int, listType string) (*models.SavedView, bool) {
+	var (
+		view *models.SavedView
+		err  error
+	)
+	if idStr := c.DefaultQuery("view_id", ""); idStr != "" {
+		id, convErr := strconv.Atoi(idStr)
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的视图ID"})
+			return nil, false
+		}
+		if view, err = models.GetSavedViewByID(id, userID); err == nil && view == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "视图不存在"})
+			return nil, false
+		}
+	} else if !hasExplicitListParams(c) {
+		view, err = models.GetDefaultView(userID, listType)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败: " + err.Error()})
+		return nil, false
+	}
+	if view != nil && view.ListType != listType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "视图不属于该列表"})
+		return nil, false
+	}
+	return view, true
+}
+
+// applyView 用视图覆盖分页排序与筛选条件
+func applyView(view *models.SavedView, opt *models.ListOptions) *filter.Filter {
+	if view.OrderBy != "" {
+		opt.OrderBy = view.OrderBy
+		opt.OrderDir = view.OrderDir
+	}
+	if view.PageSize > 0 {
+		opt.PageSize = view.PageSize
+	}
+	if view.Filter == nil {
+		return &filter.Filter{}
+	}
+	return view.Filter
+}
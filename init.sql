@@ -43,20 +43,415 @@ CREATE TABLE `cc_product` (
   `size` VARCHAR(50) DEFAULT NULL COMMENT '尺码',
   `quantity` INT DEFAULT 0 COMMENT '件数（自动从尺码解析）',
   `address` TEXT DEFAULT NULL COMMENT '收件地址',
+  `mark` TEXT DEFAULT NULL COMMENT '备注',
   `status_note_photo` VARCHAR(500) DEFAULT NULL COMMENT '货物状态备注图片',
+  `photo_hash` CHAR(64) NOT NULL DEFAULT '' COMMENT '照片内容哈希（重复检测）',
+  `paid` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '客户是否已付清（由收款记录计算）',
+  `paid_amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '已付金额（由收款记录计算）',
+  `refunded_amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退给客户的金额（由退货记录计算）',
+  `return_adjustment` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退货对利润的调整（由退货记录计算）',
   `cost_eur` DECIMAL(10,2) DEFAULT 0.00 COMMENT '成本欧元',
   `exchange_rate` DECIMAL(10,4) DEFAULT 0.0000 COMMENT '结账汇率',
   `cost_rmb` DECIMAL(10,2) DEFAULT 0.00 COMMENT '成本RMB（自动计算）',
   `price_rmb` DECIMAL(10,2) DEFAULT 0.00 COMMENT '售价RMB',
   `shipping_fee` DECIMAL(10,2) DEFAULT 0.00 COMMENT '国际运费与清关费',
+  `vat_refund` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退税（自动计算，冲减成本）',
+  `card_fee` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '刷卡手续费（自动计算）',
+  `commission` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '佣金（自动计算）',
+  `other_cost` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '其他成本（自动计算）',
+  `shipping_confirmed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '运费已确认，0表示按重量尺寸自动估算',
+  `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '实际重量kg',
+  `length` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '长cm',
+  `width` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '宽cm',
+  `height` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '高cm',
+  `route` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '运输线路，空表示默认线路',
+  `sorted_at` DATETIME DEFAULT NULL COMMENT '扫码分拣时间，NULL表示未分拣',
+  `outbound_status` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '发货状态 packed/shipped/delivered，空表示未装入包裹',
   `total_cost` DECIMAL(10,2) DEFAULT 0.00 COMMENT '总成本（自动计算）',
   `profit` DECIMAL(10,2) DEFAULT 0.00 COMMENT '净利润（自动计算）',
   `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   KEY `idx_user_id` (`user_id`),
-  KEY `idx_area_id` (`area_id`)
+  KEY `idx_area_id` (`area_id`),
+  KEY `idx_photo_hash` (`photo_hash`),
+  KEY `idx_customer_name` (`customer_name`),
+  FULLTEXT KEY `ft_product_search` (`customer_name`, `brand`, `size`, `address`, `mark`) WITH PARSER ngram
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品表';
 
+-- ----------------------------
+-- 到货图表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_arrival`;
+CREATE TABLE `cc_arrival` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '用户ID',
+  `arrival_photo` VARCHAR(500) DEFAULT '' COMMENT '到货照片',
+  `quantity` VARCHAR(100) DEFAULT '' COMMENT '到货件数',
+  `brand` VARCHAR(200) DEFAULT '' COMMENT '到货品牌',
+  `box_number` VARCHAR(200) DEFAULT '' COMMENT '到货箱子单号',
+  `arrival_date` VARCHAR(100) DEFAULT '' COMMENT '到货时间日期',
+  `confirm_person` VARCHAR(100) DEFAULT '' COMMENT '到货点数确认人员',
+  `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '到货重量kg',
+  `length` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '箱子长cm',
+  `width` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '箱子宽cm',
+  `height` DECIMAL(10,1) NOT NULL DEFAULT 0.0 COMMENT '箱子高cm',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_arrival_date` (`arrival_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='到货图表';
+
+-- ----------------------------
+-- 到货明细表（到货记录与商品的关联）
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_arrival_item`;
+CREATE TABLE `cc_arrival_item` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `arrival_id` INT NOT NULL COMMENT '到货记录ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `user_id` INT NOT NULL COMMENT '操作用户ID',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY `uk_arrival_product` (`arrival_id`, `product_id`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='到货明细表';
+
+-- ----------------------------
+-- 列表视图表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_saved_view`;
+CREATE TABLE `cc_saved_view` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '用户ID',
+  `list_type` VARCHAR(20) NOT NULL DEFAULT 'product' COMMENT '列表类型 product/arrival',
+  `name` VARCHAR(100) NOT NULL COMMENT '视图名称',
+  `filter` TEXT NOT NULL COMMENT '筛选条件JSON',
+  `order_by` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '排序字段',
+  `order_dir` VARCHAR(4) NOT NULL DEFAULT 'DESC' COMMENT '排序方向',
+  `columns` TEXT NOT NULL COMMENT '可见列JSON',
+  `page_size` INT NOT NULL DEFAULT 0 COMMENT '每页条数，0为不指定',
+  `is_shared` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否共享给其他用户',
+  `is_default` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否为默认视图',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_user_list` (`user_id`, `list_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='列表视图表';
+
+-- ----------------------------
+-- 标签表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_tag`;
+CREATE TABLE `cc_tag` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建用户ID',
+  `name` VARCHAR(50) NOT NULL COMMENT '标签名称',
+  `color` VARCHAR(20) NOT NULL DEFAULT '#909399' COMMENT '标签颜色',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='标签表';
+
+-- ----------------------------
+-- 商品标签关联表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_product_tag`;
+CREATE TABLE `cc_product_tag` (
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `tag_id` INT NOT NULL COMMENT '标签ID',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`product_id`, `tag_id`),
+  KEY `idx_tag_id` (`tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品标签关联表';
+
+-- ----------------------------
+-- 商品附件表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_product_attachment`;
+CREATE TABLE `cc_product_attachment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `user_id` INT NOT NULL COMMENT '上传用户ID',
+  `kind` VARCHAR(20) NOT NULL DEFAULT 'product' COMMENT '类型 product/receipt/status/damage',
+  `url` VARCHAR(500) NOT NULL COMMENT '文件URL',
+  `caption` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '说明',
+  `sort_order` INT NOT NULL DEFAULT 0 COMMENT '排序',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY `idx_product_id` (`product_id`, `sort_order`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品附件表';
+
+-- ----------------------------
+-- 评论表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_comment`;
+CREATE TABLE `cc_comment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `target_type` VARCHAR(20) NOT NULL COMMENT '对象类型 product/arrival',
+  `target_id` INT NOT NULL COMMENT '对象ID',
+  `parent_id` INT NOT NULL DEFAULT 0 COMMENT '顶层评论ID，0为顶层',
+  `user_id` INT NOT NULL COMMENT '作者ID',
+  `content` TEXT NOT NULL COMMENT '内容',
+  `images` TEXT NOT NULL COMMENT '图片URL列表JSON',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY `idx_target` (`target_type`, `target_id`),
+  KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='评论表';
+
+-- ----------------------------
+-- 评论提及表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_comment_mention`;
+CREATE TABLE `cc_comment_mention` (
+  `comment_id` INT NOT NULL COMMENT '评论ID',
+  `user_id` INT NOT NULL COMMENT '被提及用户ID',
+  `read_at` TIMESTAMP NULL DEFAULT NULL COMMENT '已读时间',
+  PRIMARY KEY (`comment_id`, `user_id`),
+  KEY `idx_user_unread` (`user_id`, `read_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='评论提及表';
+
+-- ----------------------------
+-- 其他费用表（月度损益）
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_expense`;
+CREATE TABLE `cc_expense` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '登记人ID',
+  `month` CHAR(7) NOT NULL COMMENT '所属月份 YYYY-MM',
+  `category` VARCHAR(50) NOT NULL COMMENT '费用类别',
+  `amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '金额RMB',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_month` (`month`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='其他费用表';
+
+-- ----------------------------
+-- 月份结账表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_period_close`;
+CREATE TABLE `cc_period_close` (
+  `month` CHAR(7) PRIMARY KEY COMMENT '已结账月份 YYYY-MM',
+  `user_id` INT NOT NULL COMMENT '结账人ID',
+  `closed_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '结账时间'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='月份结账表';
+
+-- ----------------------------
+-- 运单表（合并发货的国际运输）
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_shipment`;
+CREATE TABLE `cc_shipment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `name` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '运单名称',
+  `tracking_no` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '运单号',
+  `freight` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '国际运费RMB',
+  `customs` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '清关费RMB',
+  `method` VARCHAR(20) NOT NULL DEFAULT 'quantity' COMMENT '分摊方式 quantity/weight/value/manual',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `allocated_at` TIMESTAMP NULL DEFAULT NULL COMMENT '最近分摊时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='运单表';
+
+-- ----------------------------
+-- 运单明细表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_shipment_item`;
+CREATE TABLE `cc_shipment_item` (
+  `shipment_id` INT NOT NULL COMMENT '运单ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '重量kg（按重量分摊）',
+  `ratio` DECIMAL(10,4) NOT NULL DEFAULT 0.0000 COMMENT '手动分摊比例',
+  `allocated` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '已分摊金额',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`shipment_id`, `product_id`),
+  UNIQUE KEY `uk_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='运单明细表';
+
+-- ----------------------------
+-- 收款表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_payment`;
+CREATE TABLE `cc_payment` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '登记人ID',
+  `product_id` INT NOT NULL DEFAULT 0 COMMENT '商品ID，0表示记在客户名下',
+  `customer_name` VARCHAR(200) NOT NULL COMMENT '客户名',
+  `kind` VARCHAR(20) NOT NULL DEFAULT 'balance' COMMENT '类型 deposit/balance/refund',
+  `method` VARCHAR(30) NOT NULL DEFAULT '' COMMENT '支付方式',
+  `amount` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '金额RMB',
+  `paid_at` DATETIME NOT NULL COMMENT '收款时间',
+  `reference` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '流水号/凭证号',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_customer_name` (`customer_name`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='收款表';
+
+-- ----------------------------
+-- 商品退货表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_product_return`;
+CREATE TABLE `cc_product_return` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `user_id` INT NOT NULL COMMENT '登记人ID',
+  `reason` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '退货原因',
+  `refund_to_customer` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退给客户的金额RMB',
+  `refund_from_supplier` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '供应商退款RMB',
+  `restocking_fee` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '退货手续费RMB',
+  `photos` TEXT NOT NULL COMMENT '退货照片URL（JSON数组）',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `returned_at` DATETIME NOT NULL COMMENT '退货时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品退货表';
+
+-- ----------------------------
+-- 分拣会话表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_scan_session`;
+CREATE TABLE `cc_scan_session` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `name` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '会话名称',
+  `arrival_id` INT NOT NULL DEFAULT 0 COMMENT '应扫范围：到货记录ID',
+  `shipment_id` INT NOT NULL DEFAULT 0 COMMENT '应扫范围：运单ID',
+  `closed_at` DATETIME DEFAULT NULL COMMENT '结束时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分拣会话表';
+
+-- ----------------------------
+-- 扫码记录表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_scan_event`;
+CREATE TABLE `cc_scan_event` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `session_id` INT NOT NULL DEFAULT 0 COMMENT '分拣会话ID，0表示不属于会话',
+  `user_id` INT NOT NULL COMMENT '扫码人ID',
+  `code` VARCHAR(200) NOT NULL COMMENT '扫描的条码',
+  `matched_by` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '匹配方式 product/box/tracking，空表示未找到',
+  `product_id` INT NOT NULL DEFAULT 0 COMMENT '唯一匹配的商品ID',
+  `area_id` INT NOT NULL DEFAULT 0 COMMENT '商品所在区域ID',
+  `sorted` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否标记为已分拣',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY `idx_session_id` (`session_id`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='扫码记录表';
+
+-- ----------------------------
+-- 拣货单表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_pick_list`;
+CREATE TABLE `cc_pick_list` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `name` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '名称',
+  `arrival_id` INT NOT NULL DEFAULT 0 COMMENT '到货记录ID，0表示按到货日期范围生成',
+  `start_time` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '到货日期开始',
+  `end_time` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '到货日期结束',
+  `completed_at` DATETIME DEFAULT NULL COMMENT '全部扫码完成时间',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='拣货单表';
+
+-- ----------------------------
+-- 拣货单明细表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_pick_list_item`;
+CREATE TABLE `cc_pick_list_item` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `pick_list_id` INT NOT NULL COMMENT '拣货单ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `scanned_at` DATETIME DEFAULT NULL COMMENT '扫码时间',
+  UNIQUE KEY `uk_list_product` (`pick_list_id`, `product_id`),
+  KEY `idx_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='拣货单明细表';
+
+-- ----------------------------
+-- 品牌目录表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_brand`;
+CREATE TABLE `cc_brand` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `name` VARCHAR(200) NOT NULL COMMENT '规范品牌名称',
+  `currency` CHAR(3) NOT NULL DEFAULT 'EUR' COMMENT '默认采购币种',
+  `supplier` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '默认供应商',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='品牌目录表';
+
+-- ----------------------------
+-- 品牌别名表（写入时别名统一为品牌名称）
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_brand_alias`;
+CREATE TABLE `cc_brand_alias` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `brand_id` INT NOT NULL COMMENT '品牌ID',
+  `alias` VARCHAR(200) NOT NULL COMMENT '别名，不区分大小写',
+  UNIQUE KEY `uk_alias` (`alias`),
+  KEY `idx_brand_id` (`brand_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='品牌别名表';
+
+-- ----------------------------
+-- 商品拆分合并记录表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_product_history`;
+CREATE TABLE `cc_product_history` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `action` VARCHAR(20) NOT NULL COMMENT '操作 split/merge',
+  `user_id` INT NOT NULL COMMENT '操作用户ID',
+  `snapshot` MEDIUMTEXT NOT NULL COMMENT '操作前商品的JSON快照',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品拆分合并记录表';
+
+-- ----------------------------
+-- 商品来源关系表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_product_lineage`;
+CREATE TABLE `cc_product_lineage` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `history_id` INT NOT NULL COMMENT '拆分合并记录ID',
+  `from_id` INT NOT NULL COMMENT '来源商品ID',
+  `to_id` INT NOT NULL COMMENT '结果商品ID',
+  KEY `idx_history_id` (`history_id`),
+  KEY `idx_from_id` (`from_id`),
+  KEY `idx_to_id` (`to_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='商品来源关系表';
+
+-- ----------------------------
+-- 发货包裹表（分拣后寄给客户的国内快递）
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_parcel`;
+CREATE TABLE `cc_parcel` (
+  `id` INT AUTO_INCREMENT PRIMARY KEY,
+  `user_id` INT NOT NULL COMMENT '创建人ID',
+  `carrier` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '快递公司',
+  `tracking_no` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '快递单号',
+  `recipient` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '收件人',
+  `address` TEXT COMMENT '收件地址',
+  `weight` DECIMAL(10,3) NOT NULL DEFAULT 0.000 COMMENT '包裹重量kg',
+  `cost` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '快递费RMB',
+  `status` VARCHAR(20) NOT NULL DEFAULT 'packed' COMMENT '状态 packed/shipped/delivered',
+  `shipped_date` DATE DEFAULT NULL COMMENT '发货日期',
+  `note` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '备注',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY `idx_tracking_no` (`tracking_no`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='发货包裹表';
+
+-- ----------------------------
+-- 发货包裹明细表
+-- ----------------------------
+DROP TABLE IF EXISTS `cc_parcel_item`;
+CREATE TABLE `cc_parcel_item` (
+  `parcel_id` INT NOT NULL COMMENT '包裹ID',
+  `product_id` INT NOT NULL COMMENT '商品ID',
+  `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`parcel_id`, `product_id`),
+  UNIQUE KEY `uk_product_id` (`product_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='发货包裹明细表';
+
 -- ----------------------------
 -- 插入初始数据
 -- ----------------------------
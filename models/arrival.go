@@ -4,35 +4,74 @@ import (
 	"database/sql"
 	"fmt"
 	"sorting-system/database"
+	"sorting-system/filter"
+	"sorting-system/search"
+	"sorting-system/validate"
 	"strings"
 )
 
 type Arrival struct {
-	ID            int    `json:"id"`
-	UserID        int    `json:"user_id"`
-	ArrivalPhoto  string `json:"arrival_photo"`
-	Quantity      string `json:"quantity"`
-	Brand         string `json:"brand"`
-	BoxNumber     string `json:"box_number"`
-	ArrivalDate   string `json:"arrival_date"`
-	ConfirmPerson string `json:"confirm_person"`
-	CreatedAt     string `json:"created_at"`
-	UpdatedAt     string `json:"updated_at"`
+	ID            int     `json:"id"`
+	UserID        int     `json:"user_id"`
+	ArrivalPhoto  string  `json:"arrival_photo"`
+	Quantity      string  `json:"quantity"`
+	Brand         string  `json:"brand"`
+	BoxNumber     string  `json:"box_number"`
+	ArrivalDate   string  `json:"arrival_date"`
+	ConfirmPerson string  `json:"confirm_person"`
+	Weight        float64 `json:"weight"`
+	Length        float64 `json:"length"`
+	Width         float64 `json:"width"`
+	Height        float64 `json:"height"`
+	// VolumetricWeight 按长宽高计算的体积重，不入库
+	VolumetricWeight float64 `json:"volumetric_weight"`
+	CreatedAt        string  `json:"created_at"`
+	UpdatedAt        string  `json:"updated_at"`
 }
 
+// ArrivalListResponse 到货列表；Total 为 -1 表示未统计
 type ArrivalListResponse struct {
-	Total    int64      `json:"total"`
-	Page     int        `json:"page"`
-	PageSize int        `json:"page_size"`
-	List     []*Arrival `json:"list"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	List       []*Arrival `json:"list"`
+	HasMore    bool       `json:"has_more"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// arrivalColumns cc_arrival 查询的标准列顺序，需与 scanArrival 保持一致
+const arrivalColumns = `id, user_id, arrival_photo, quantity, brand, box_number, arrival_date, confirm_person,
+		created_at, updated_at, weight, length, width, height`
+
+// scanArrival 按 arrivalColumns 的顺序扫描一行到货记录
+func scanArrival(row rowScanner) (*Arrival, error) {
+	a := &Arrival{}
+	err := row.Scan(
+		&a.ID, &a.UserID, &a.ArrivalPhoto, &a.Quantity, &a.Brand, &a.BoxNumber, &a.ArrivalDate, &a.ConfirmPerson,
+		&a.CreatedAt, &a.UpdatedAt, &a.Weight, &a.Length, &a.Width, &a.Height,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.VolumetricWeight = VolumetricWeight(a.Length, a.Width, a.Height)
+	return a, nil
 }
 
 func CreateArrival(a *Arrival) error {
+	brand, err := NormalizeBrand(a.Brand)
+	if err != nil {
+		return err
+	}
+	a.Brand = brand
+	if err := ValidateArrival(a, nil); err != nil {
+		return err
+	}
 	result, err := database.DB.Exec(
 		`INSERT INTO cc_arrival
-		(user_id, arrival_photo, quantity, brand, box_number, arrival_date, confirm_person)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		(user_id, arrival_photo, quantity, brand, box_number, arrival_date, confirm_person, weight, length, width, height)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		a.UserID, a.ArrivalPhoto, a.Quantity, a.Brand, a.BoxNumber, a.ArrivalDate, a.ConfirmPerson,
+		a.Weight, a.Length, a.Width, a.Height,
 	)
 	if err != nil {
 		return err
@@ -43,17 +82,32 @@ func CreateArrival(a *Arrival) error {
 		return err
 	}
 	a.ID = int(id)
+	a.VolumetricWeight = VolumetricWeight(a.Length, a.Width, a.Height)
 	return nil
 }
 
 func UpdateArrival(a *Arrival) error {
-	_, err := database.DB.Exec(
+	old, err := GetArrivalByID(a.ID, a.UserID)
+	if err != nil {
+		return err
+	}
+	if a.Brand, err = NormalizeBrand(a.Brand); err != nil {
+		return err
+	}
+	if err := ValidateArrival(a, old); err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(
 		`UPDATE cc_arrival SET
-		arrival_photo=?, quantity=?, brand=?, box_number=?, arrival_date=?, confirm_person=?
+		arrival_photo=?, quantity=?, brand=?, box_number=?, arrival_date=?, confirm_person=?,
+		weight=?, length=?, width=?, height=?
 		WHERE id=? AND user_id=?`,
 		a.ArrivalPhoto, a.Quantity, a.Brand, a.BoxNumber, a.ArrivalDate, a.ConfirmPerson,
+		a.Weight, a.Length, a.Width, a.Height,
 		a.ID, a.UserID,
 	)
+	a.VolumetricWeight = VolumetricWeight(a.Length, a.Width, a.Height)
 	return err
 }
 
@@ -67,20 +121,36 @@ func UpdateArrivalField(id, userID int, field string, value interface{}) (*Arriv
 		return nil, fmt.Errorf("记录不存在")
 	}
 
+	// 转换并校验字段值，不支持的字段和类型不符的值返回校验错误
+	v, fe := ArrivalSchema.Coerce(field, value)
+	if fe != nil {
+		return nil, validate.Errors{fe}
+	}
+	str, _ := v.(string)
+	num, _ := v.(float64)
+
 	// 更新指定字段
 	switch field {
 	case "arrival_photo":
-		arrival.ArrivalPhoto = value.(string)
+		arrival.ArrivalPhoto = str
 	case "quantity":
-		arrival.Quantity = value.(string)
+		arrival.Quantity = str
 	case "brand":
-		arrival.Brand = value.(string)
+		arrival.Brand = str
 	case "box_number":
-		arrival.BoxNumber = value.(string)
+		arrival.BoxNumber = str
 	case "arrival_date":
-		arrival.ArrivalDate = value.(string)
+		arrival.ArrivalDate = str
 	case "confirm_person":
-		arrival.ConfirmPerson = value.(string)
+		arrival.ConfirmPerson = str
+	case "weight":
+		arrival.Weight = num
+	case "length":
+		arrival.Length = num
+	case "width":
+		arrival.Width = num
+	case "height":
+		arrival.Height = num
 	}
 
 	// 保存
@@ -106,23 +176,35 @@ func DeleteArrivals(ids []int, userID int) error {
 		args[i+1] = id
 	}
 
+	// 只能删除自己的到货记录，先取出其ID用于清理关联数据
+	owned, err := queryIDs(fmt.Sprintf("SELECT id FROM cc_arrival WHERE user_id=? AND id IN (%s)",
+		strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return err
+	}
+
 	query := fmt.Sprintf("DELETE FROM cc_arrival WHERE user_id=? AND id IN (%s)",
 		strings.Join(placeholders, ","))
-	_, err := database.DB.Exec(query, args...)
-	return err
+	if _, err := database.DB.Exec(query, args...); err != nil {
+		return err
+	}
+
+	// 清理已删除到货记录的明细关联
+	query = fmt.Sprintf("DELETE FROM cc_arrival_item WHERE arrival_id IN (%s) AND arrival_id NOT IN (SELECT id FROM cc_arrival)",
+		strings.Join(placeholders, ","))
+	if _, err := database.DB.Exec(query, args[1:]...); err != nil {
+		return err
+	}
+
+	// 清理已删除到货记录上的评论及其提及
+	return deleteTargetComments(database.DB, CommentTargetArrival, owned)
 }
 
 func GetArrivalByID(id, userID int) (*Arrival, error) {
-	a := &Arrival{}
-	err := database.DB.QueryRow(
-		`SELECT id, user_id, arrival_photo, quantity, brand, box_number, arrival_date, confirm_person,
-		created_at, updated_at
-		FROM cc_arrival WHERE id=? AND user_id=?`,
+	a, err := scanArrival(database.DB.QueryRow(
+		`SELECT `+arrivalColumns+` FROM cc_arrival WHERE id=? AND user_id=?`,
 		id, userID,
-	).Scan(
-		&a.ID, &a.UserID, &a.ArrivalPhoto, &a.Quantity, &a.Brand, &a.BoxNumber, &a.ArrivalDate, &a.ConfirmPerson,
-		&a.CreatedAt, &a.UpdatedAt,
-	)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -133,63 +215,111 @@ func GetArrivalByID(id, userID int) (*Arrival, error) {
 	return a, nil
 }
 
-func GetArrivalList(userID, page, pageSize int, orderBy, orderDir, keyword, startTime, endTime string) (*ArrivalListResponse, error) {
-	// 验证排序字段
-	validOrderFields := map[string]bool{
-		"id": true, "quantity": true, "brand": true, "box_number": true,
-		"arrival_date": true, "confirm_person": true, "created_at": true, "updated_at": true,
-	}
-	if orderBy == "" {
-		orderBy = "id"
+// ArrivalSearchSchema 到货列表支持的搜索与筛选字段
+var ArrivalSearchSchema = &search.Schema{
+	Fields: map[string]search.Field{
+		"id":             {Column: "id", Kind: search.KindNumber},
+		"arrival_photo":  {Column: "arrival_photo", Kind: search.KindText},
+		"quantity":       {Column: "quantity", Kind: search.KindText},
+		"brand":          {Column: "brand", Kind: search.KindText},
+		"品牌":             {Column: "brand", Kind: search.KindText},
+		"box_number":     {Column: "box_number", Kind: search.KindText},
+		"箱号":             {Column: "box_number", Kind: search.KindText},
+		"confirm_person": {Column: "confirm_person", Kind: search.KindText},
+		"weight":         {Column: "weight", Kind: search.KindNumber},
+		"重量":             {Column: "weight", Kind: search.KindNumber},
+		"arrival_date":   {Column: "arrival_date", Kind: search.KindDate},
+		"created_at":     {Column: "created_at", Kind: search.KindDate},
+		"updated_at":     {Column: "updated_at", Kind: search.KindDate},
+	},
+	LikeColumns: []string{"quantity", "brand", "box_number", "confirm_person"},
+	IDColumn:    "id",
+}
+
+// arrivalSortExprs 可排序字段及排序表达式
+var arrivalSortExprs = map[string]string{
+	"id": "id", "quantity": "COALESCE(quantity, '')", "brand": "COALESCE(brand, '')",
+	"box_number": "COALESCE(box_number, '')", "arrival_date": "arrival_date",
+	"confirm_person": "COALESCE(confirm_person, '')", "created_at": "created_at", "updated_at": "updated_at",
+}
+
+// arrivalSortValue 取到货记录在排序列上的值，用于生成游标
+func arrivalSortValue(a *Arrival, orderBy string) interface{} {
+	switch orderBy {
+	case "quantity":
+		return a.Quantity
+	case "brand":
+		return a.Brand
+	case "box_number":
+		return a.BoxNumber
+	case "arrival_date":
+		return sortTimeValue(a.ArrivalDate)
+	case "confirm_person":
+		return a.ConfirmPerson
+	case "created_at":
+		return sortTimeValue(a.CreatedAt)
+	case "updated_at":
+		return sortTimeValue(a.UpdatedAt)
 	}
-	if !validOrderFields[orderBy] {
+	return a.ID
+}
+
+func GetArrivalList(userID int, opt ListOptions, f *filter.Filter) (*ArrivalListResponse, error) {
+	// 验证排序字段
+	orderBy, orderDir := opt.OrderBy, opt.OrderDir
+	if _, ok := arrivalSortExprs[orderBy]; !ok {
 		orderBy = "id"
 	}
 	if orderDir != "ASC" && orderDir != "DESC" {
 		orderDir = "DESC"
 	}
+	sortExpr := arrivalSortExprs[orderBy]
 
 	// 构建WHERE条件
-	whereClause := "WHERE user_id=?"
-	args := []interface{}{userID}
-
-	if keyword != "" {
-		whereClause += " AND (quantity LIKE ? OR brand LIKE ? OR box_number LIKE ? OR confirm_person LIKE ?)"
-		searchPattern := "%" + keyword + "%"
-		args = append(args, searchPattern, searchPattern, searchPattern, searchPattern)
+	whereClause, args, err := f.Where(ArrivalSearchSchema, "WHERE user_id=?", []interface{}{userID})
+	if err != nil {
+		return nil, err
 	}
 
-	// 添加时间范围查询
-	if startTime != "" {
-		whereClause += " AND arrival_date >= ?"
-		args = append(args, startTime)
+	resp := &ArrivalListResponse{
+		Total:    -1,
+		Page:     opt.Page,
+		PageSize: opt.PageSize,
 	}
-	if endTime != "" {
-		whereClause += " AND arrival_date <= ?"
-		args = append(args, endTime)
+
+	// 获取总数，游标翻页时沿用首页结果
+	if opt.Cursor == "" && !opt.SkipTotal {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM cc_arrival %s", whereClause)
+		if err := database.DB.QueryRow(countQuery, args...).Scan(&resp.Total); err != nil {
+			return nil, err
+		}
 	}
 
-	// 获取总数
-	var total int64
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM cc_arrival %s", whereClause)
-	err := database.DB.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, err
+	// 获取列表，多取一条判断是否还有下一页
+	pageWhere, pageArgs := whereClause, append([]interface{}{}, args...)
+	limitClause := "LIMIT ? OFFSET ?"
+	limitArgs := []interface{}{opt.PageSize + 1, (opt.Page - 1) * opt.PageSize}
+	if opt.Cursor != "" {
+		c, err := decodeCursor(opt.Cursor, orderBy, orderDir)
+		if err != nil {
+			return nil, err
+		}
+		clause, clauseArgs := keysetClause(sortExpr, c)
+		pageWhere += " AND " + clause
+		pageArgs = append(pageArgs, clauseArgs...)
+		limitClause = "LIMIT ?"
+		limitArgs = limitArgs[:1]
 	}
 
-	// 获取列表
-	offset := (page - 1) * pageSize
 	query := fmt.Sprintf(`
-		SELECT id, user_id, arrival_photo, quantity, brand, box_number, arrival_date, confirm_person,
-		created_at, updated_at
+		SELECT %s
 		FROM cc_arrival
 		%s
-		ORDER BY %s %s
-		LIMIT ? OFFSET ?
-	`, whereClause, orderBy, orderDir)
+		ORDER BY %s %s, id %s
+		%s
+	`, arrivalColumns, pageWhere, sortExpr, orderDir, orderDir, limitClause)
 
-	queryArgs := append(args, pageSize, offset)
-	rows, err := database.DB.Query(query, queryArgs...)
+	rows, err := database.DB.Query(query, append(pageArgs, limitArgs...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -197,21 +327,25 @@ func GetArrivalList(userID, page, pageSize int, orderBy, orderDir, keyword, star
 
 	list := []*Arrival{}
 	for rows.Next() {
-		a := &Arrival{}
-		err := rows.Scan(
-			&a.ID, &a.UserID, &a.ArrivalPhoto, &a.Quantity, &a.Brand, &a.BoxNumber, &a.ArrivalDate, &a.ConfirmPerson,
-			&a.CreatedAt, &a.UpdatedAt,
-		)
+		a, err := scanArrival(rows)
 		if err != nil {
 			return nil, err
 		}
 		list = append(list, a)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(list) > opt.PageSize {
+		list = list[:opt.PageSize]
+		resp.HasMore = true
+	}
+	if resp.HasMore && len(list) > 0 {
+		last := list[len(list)-1]
+		resp.NextCursor = encodeCursor(orderBy, orderDir, arrivalSortValue(last, orderBy), last.ID)
+	}
+	resp.List = list
 
-	return &ArrivalListResponse{
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-		List:     list,
-	}, nil
+	return resp, nil
 }
@@ -0,0 +1,286 @@
+package models
+
+import (
+	"database/sql"
+	"sorting-system/database"
+)
+
+// 附件类型
+const (
+	AttachmentKindProduct = "product"
+	AttachmentKindReceipt = "receipt"
+	AttachmentKindStatus  = "status"
+	AttachmentKindDamage  = "damage"
+)
+
+// ValidAttachmentKinds 支持的附件类型
+var ValidAttachmentKinds = map[string]bool{
+	AttachmentKindProduct: true,
+	AttachmentKindReceipt: true,
+	AttachmentKindStatus:  true,
+	AttachmentKindDamage:  true,
+}
+
+// Attachment 商品附件（图片或文件）
+//
+// product 类型的主图对应 cc_product.photo，status 类型的主图对应 cc_product.status_note_photo
+type Attachment struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	UserID    int    `json:"user_id"`
+	Kind      string `json:"kind"`
+	URL       string `json:"url"`
+	Caption   string `json:"caption"`
+	SortOrder int    `json:"sort_order"`
+	IsPrimary bool   `json:"is_primary"`
+	CreatedAt string `json:"created_at"`
+}
+
+const attachmentColumns = `id, product_id, user_id, kind, url, caption, sort_order, created_at`
+
+func scanAttachment(row rowScanner) (*Attachment, error) {
+	a := &Attachment{}
+	err := row.Scan(&a.ID, &a.ProductID, &a.UserID, &a.Kind, &a.URL, &a.Caption, &a.SortOrder, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// primaryColumn 附件类型对应的商品主图字段，没有则返回空
+func primaryColumn(kind string) string {
+	switch kind {
+	case AttachmentKindProduct:
+		return "photo"
+	case AttachmentKindStatus:
+		return "status_note_photo"
+	}
+	return ""
+}
+
+// CreateAttachment 添加附件，排在同一商品附件的最后
+// primary 为 true 或商品对应主图为空时，同时设为主图
+func CreateAttachment(a *Attachment, primary bool) error {
+	err := database.DB.QueryRow(
+		`SELECT COALESCE(MAX(sort_order), 0) + 1 FROM cc_product_attachment WHERE product_id=?`,
+		a.ProductID,
+	).Scan(&a.SortOrder)
+	if err != nil {
+		return err
+	}
+
+	result, err := database.DB.Exec(
+		`INSERT INTO cc_product_attachment (product_id, user_id, kind, url, caption, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.ProductID, a.UserID, a.Kind, a.URL, a.Caption, a.SortOrder,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.ID = int(id)
+
+	column := primaryColumn(a.Kind)
+	if column == "" {
+		return nil
+	}
+	if !primary {
+		var current sql.NullString
+		err := database.DB.QueryRow(`SELECT `+column+` FROM cc_product WHERE id=?`, a.ProductID).Scan(&current)
+		if err != nil {
+			return err
+		}
+		primary = current.String == ""
+	}
+	if primary {
+		return setPrimaryImage(a.ProductID, column, a.URL)
+	}
+	return nil
+}
+
+// UpdateAttachmentCaption 修改附件说明，附件不存在时返回 sql.ErrNoRows
+func UpdateAttachmentCaption(id, productID int, caption string) error {
+	a, err := GetAttachmentByID(id, productID)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return sql.ErrNoRows
+	}
+	_, err = database.DB.Exec(
+		`UPDATE cc_product_attachment SET caption=? WHERE id=? AND product_id=?`,
+		caption, id, productID,
+	)
+	return err
+}
+
+// SetPrimaryAttachment 将附件设为商品主图
+func SetPrimaryAttachment(id, productID int) error {
+	a, err := GetAttachmentByID(id, productID)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return sql.ErrNoRows
+	}
+	column := primaryColumn(a.Kind)
+	if column == "" {
+		return nil
+	}
+	return setPrimaryImage(productID, column, a.URL)
+}
+
+// DeleteAttachment 删除附件，若为主图则由同类型的下一张附件接替
+func DeleteAttachment(id, productID int) error {
+	a, err := GetAttachmentByID(id, productID)
+	if err != nil || a == nil {
+		return err
+	}
+
+	if _, err := database.DB.Exec(`DELETE FROM cc_product_attachment WHERE id=?`, id); err != nil {
+		return err
+	}
+
+	column := primaryColumn(a.Kind)
+	if column == "" {
+		return nil
+	}
+
+	var current sql.NullString
+	err = database.DB.QueryRow(`SELECT `+column+` FROM cc_product WHERE id=?`, productID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.String != a.URL {
+		return nil
+	}
+
+	next := ""
+	err = database.DB.QueryRow(
+		`SELECT url FROM cc_product_attachment WHERE product_id=? AND kind=? ORDER BY sort_order ASC, id ASC LIMIT 1`,
+		productID, a.Kind,
+	).Scan(&next)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	return setPrimaryImage(productID, column, next)
+}
+
+// ReorderAttachments 按给定ID顺序重排附件
+func ReorderAttachments(productID int, ids []int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, id := range ids {
+		_, err := tx.Exec(
+			`UPDATE cc_product_attachment SET sort_order=? WHERE id=? AND product_id=?`,
+			i+1, id, productID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAttachmentByID 获取商品下的单个附件
+func GetAttachmentByID(id, productID int) (*Attachment, error) {
+	a, err := scanAttachment(database.DB.QueryRow(
+		`SELECT `+attachmentColumns+` FROM cc_product_attachment WHERE id=? AND product_id=?`,
+		id, productID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAttachmentList 获取商品的全部附件，kind 为空时不限类型
+func GetAttachmentList(product *Product, kind string) ([]*Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM cc_product_attachment WHERE product_id=?`
+	args := []interface{}{product.ID}
+	if kind != "" {
+		query += " AND kind=?"
+		args = append(args, kind)
+	}
+	query += " ORDER BY sort_order ASC, id ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		switch a.Kind {
+		case AttachmentKindProduct:
+			a.IsPrimary = a.URL == product.Photo
+		case AttachmentKindStatus:
+			a.IsPrimary = a.URL == product.StatusNotePhoto
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// syncPrimaryAttachments 商品的主图和状态图直接修改时补录为附件，已有相同URL的附件则不重复添加
+func syncPrimaryAttachments(p *Product, userID int) error {
+	images := []struct{ kind, url string }{
+		{AttachmentKindProduct, p.Photo},
+		{AttachmentKindStatus, p.StatusNotePhoto},
+	}
+	for _, img := range images {
+		if img.url == "" {
+			continue
+		}
+		var exists int
+		err := database.DB.QueryRow(
+			`SELECT COUNT(*) FROM cc_product_attachment WHERE product_id=? AND kind=? AND url=?`,
+			p.ID, img.kind, img.url,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		_, err = database.DB.Exec(
+			`INSERT INTO cc_product_attachment (product_id, user_id, kind, url, caption, sort_order)
+			SELECT ?, ?, ?, ?, '', COALESCE(MAX(sort_order), 0) + 1 FROM cc_product_attachment WHERE product_id=?`,
+			p.ID, userID, img.kind, img.url, p.ID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPrimaryImage 写入商品主图字段，photo 同时更新内容哈希
+func setPrimaryImage(productID int, column, url string) error {
+	if column == "photo" {
+		_, err := database.DB.Exec(`UPDATE cc_product SET photo=?, photo_hash=? WHERE id=?`,
+			url, photoHash(url), productID)
+		return err
+	}
+	_, err := database.DB.Exec(`UPDATE cc_product SET `+column+`=? WHERE id=?`, url, productID)
+	return err
+}
@@ -0,0 +1,456 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sorting-system/database"
+	"strings"
+)
+
+// Brand 品牌目录，商品与到货记录写入时品牌文字会按名称和别名统一为 Name
+type Brand struct {
+	ID        int      `json:"id"`
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases"`
+	Currency  string   `json:"currency"` // 默认采购币种
+	Supplier  string   `json:"supplier"` // 默认供应商
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// BrandVariant 商品与到货记录中出现的品牌文字，Brand 为已匹配的目录品牌，未匹配时为空
+type BrandVariant struct {
+	Text     string `json:"text"`
+	Count    int    `json:"count"`
+	BrandID  int    `json:"brand_id"`
+	Brand    string `json:"brand"`
+	Resolved bool   `json:"resolved"` // 文字与目录品牌名称完全一致
+}
+
+// BrandMergeResult 合并品牌后改写的记录数
+type BrandMergeResult struct {
+	Brand    *Brand `json:"brand"`
+	Products int64  `json:"products"`
+	Arrivals int64  `json:"arrivals"`
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern 生成包含匹配的 LIKE 参数
+func likePattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// cleanBrandText 去掉首尾空白并合并连续空白
+func cleanBrandText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// NormalizeBrand 将品牌文字统一为目录中的品牌名称，名称与别名不区分大小写；不在目录中时只整理空白
+func NormalizeBrand(text string) (string, error) {
+	text = cleanBrandText(text)
+	if text == "" {
+		return "", nil
+	}
+
+	var name string
+	err := database.DB.QueryRow(
+		`SELECT name FROM cc_brand WHERE name=?
+		UNION ALL
+		SELECT b.name FROM cc_brand_alias a JOIN cc_brand b ON b.id = a.brand_id WHERE a.alias=?
+		LIMIT 1`,
+		text, text,
+	).Scan(&name)
+	if err == sql.ErrNoRows {
+		return text, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// cleanBrand 整理品牌的名称与别名：去掉空白、与名称相同及重复的别名
+func cleanBrand(b *Brand) {
+	b.Name = cleanBrandText(b.Name)
+	b.Currency = strings.ToUpper(strings.TrimSpace(b.Currency))
+	if b.Currency == "" {
+		b.Currency = "EUR"
+	}
+	b.Supplier = strings.TrimSpace(b.Supplier)
+
+	aliases := make([]string, 0, len(b.Aliases))
+	for _, alias := range b.Aliases {
+		alias = cleanBrandText(alias)
+		if alias == "" || strings.EqualFold(alias, b.Name) {
+			continue
+		}
+		dup := false
+		for _, a := range aliases {
+			if strings.EqualFold(a, alias) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			aliases = append(aliases, alias)
+		}
+	}
+	b.Aliases = aliases
+}
+
+// checkBrandConflict 名称或别名已属于 exclude 之外的其他品牌时返回错误
+func checkBrandConflict(tx *sql.Tx, texts []string, exclude []int) error {
+	for _, text := range texts {
+		var id int
+		var name string
+		err := tx.QueryRow(
+			`SELECT id, name FROM cc_brand WHERE name=?
+			UNION ALL
+			SELECT b.id, b.name FROM cc_brand_alias a JOIN cc_brand b ON b.id = a.brand_id WHERE a.alias=?`,
+			text, text,
+		).Scan(&id, &name)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		excluded := false
+		for _, e := range exclude {
+			if e == id {
+				excluded = true
+			}
+		}
+		if !excluded {
+			return fmt.Errorf("%s 已属于品牌 %s", text, name)
+		}
+	}
+	return nil
+}
+
+// saveBrandAliases 用 aliases 替换品牌的全部别名
+func saveBrandAliases(tx *sql.Tx, brandID int, aliases []string) error {
+	if _, err := tx.Exec(`DELETE FROM cc_brand_alias WHERE brand_id=?`, brandID); err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		if _, err := tx.Exec(`INSERT INTO cc_brand_alias (brand_id, alias) VALUES (?, ?)`, brandID, alias); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateBrand 创建品牌，名称与别名不能与其他品牌重复
+func CreateBrand(b *Brand) error {
+	cleanBrand(b)
+	if b.Name == "" {
+		return fmt.Errorf("品牌名称不能为空")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkBrandConflict(tx, append([]string{b.Name}, b.Aliases...), nil); err != nil {
+		return err
+	}
+	result, err := tx.Exec(
+		`INSERT INTO cc_brand (name, currency, supplier) VALUES (?, ?, ?)`,
+		b.Name, b.Currency, b.Supplier,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	b.ID = int(id)
+
+	if err := saveBrandAliases(tx, b.ID, b.Aliases); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateBrand 修改品牌名称、别名与默认币种、供应商
+func UpdateBrand(b *Brand) error {
+	cleanBrand(b)
+	if b.Name == "" {
+		return fmt.Errorf("品牌名称不能为空")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkBrandConflict(tx, append([]string{b.Name}, b.Aliases...), []int{b.ID}); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`UPDATE cc_brand SET name=?, currency=?, supplier=? WHERE id=?`,
+		b.Name, b.Currency, b.Supplier, b.ID,
+	); err != nil {
+		return err
+	}
+	if err := saveBrandAliases(tx, b.ID, b.Aliases); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteBrand 删除品牌及其别名，商品上的品牌文字保持不变
+func DeleteBrand(id int) error {
+	if _, err := database.DB.Exec(`DELETE FROM cc_brand WHERE id=?`, id); err != nil {
+		return err
+	}
+	_, err := database.DB.Exec(`DELETE FROM cc_brand_alias WHERE brand_id=?`, id)
+	return err
+}
+
+// loadBrandAliases 为品牌填充别名
+func loadBrandAliases(brands []*Brand) error {
+	if len(brands) == 0 {
+		return nil
+	}
+	byID := make(map[int]*Brand, len(brands))
+	args := make([]interface{}, len(brands))
+	for i, b := range brands {
+		b.Aliases = []string{}
+		byID[b.ID] = b
+		args[i] = b.ID
+	}
+
+	rows, err := database.DB.Query(
+		fmt.Sprintf(`SELECT brand_id, alias FROM cc_brand_alias WHERE brand_id IN (%s) ORDER BY id ASC`, placeholders(len(brands))),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var brandID int
+		var alias string
+		if err := rows.Scan(&brandID, &alias); err != nil {
+			return err
+		}
+		if b, ok := byID[brandID]; ok {
+			b.Aliases = append(b.Aliases, alias)
+		}
+	}
+	return rows.Err()
+}
+
+// queryBrands 查询品牌及其别名
+func queryBrands(query string, args ...interface{}) ([]*Brand, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Brand, 0)
+	for rows.Next() {
+		b := &Brand{}
+		if err := rows.Scan(&b.ID, &b.Name, &b.Currency, &b.Supplier, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, loadBrandAliases(list)
+}
+
+const brandColumns = `id, name, currency, supplier, created_at, updated_at`
+
+// GetBrandByID 根据ID获取品牌
+func GetBrandByID(id int) (*Brand, error) {
+	list, err := queryBrands(`SELECT `+brandColumns+` FROM cc_brand WHERE id=?`, id)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+// GetBrandList 获取品牌目录，按名称排序
+func GetBrandList() ([]*Brand, error) {
+	return queryBrands(`SELECT ` + brandColumns + ` FROM cc_brand ORDER BY name ASC`)
+}
+
+// SuggestBrands 按名称或别名查找品牌用于输入联想，完全匹配、前缀匹配的排在前面
+func SuggestBrands(q string, limit int) ([]*Brand, error) {
+	q = cleanBrandText(q)
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	if q == "" {
+		return []*Brand{}, nil
+	}
+
+	list, err := queryBrands(
+		`SELECT `+brandColumns+` FROM cc_brand b
+		WHERE b.name LIKE ? OR EXISTS (SELECT 1 FROM cc_brand_alias a WHERE a.brand_id = b.id AND a.alias LIKE ?)
+		ORDER BY b.name ASC LIMIT 200`,
+		likePattern(q), likePattern(q),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0 完全匹配，1 名称前缀，2 别名前缀，3 包含
+	lower := strings.ToLower(q)
+	rank := func(b *Brand) int {
+		best := 3
+		for i, text := range append([]string{b.Name}, b.Aliases...) {
+			text = strings.ToLower(text)
+			switch {
+			case text == lower:
+				return 0
+			case strings.HasPrefix(text, lower) && i == 0:
+				best = min(best, 1)
+			case strings.HasPrefix(text, lower):
+				best = min(best, 2)
+			}
+		}
+		return best
+	}
+	sort.SliceStable(list, func(i, j int) bool { return rank(list[i]) < rank(list[j]) })
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// GetBrandVariants 统计商品与到货记录中的品牌文字及其匹配的目录品牌，用于发现需要合并的写法
+func GetBrandVariants() ([]*BrandVariant, error) {
+	brands, err := GetBrandList()
+	if err != nil {
+		return nil, err
+	}
+	lookup := map[string]*Brand{}
+	for _, b := range brands {
+		lookup[strings.ToLower(b.Name)] = b
+		for _, alias := range b.Aliases {
+			lookup[strings.ToLower(alias)] = b
+		}
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT v.brand, SUM(v.n) FROM (
+			SELECT TRIM(brand) AS brand, COUNT(*) AS n FROM cc_product WHERE TRIM(COALESCE(brand, '')) <> '' GROUP BY TRIM(brand)
+			UNION ALL
+			SELECT TRIM(brand), COUNT(*) FROM cc_arrival WHERE TRIM(COALESCE(brand, '')) <> '' GROUP BY TRIM(brand)
+		) v
+		GROUP BY v.brand
+		ORDER BY SUM(v.n) DESC, v.brand ASC
+		LIMIT 1000`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*BrandVariant, 0)
+	for rows.Next() {
+		v := &BrandVariant{}
+		if err := rows.Scan(&v.Text, &v.Count); err != nil {
+			return nil, err
+		}
+		if b, ok := lookup[strings.ToLower(cleanBrandText(v.Text))]; ok {
+			v.BrandID, v.Brand = b.ID, b.Name
+			v.Resolved = v.Text == b.Name
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+// MergeBrands 将其他品牌和品牌文字合并到目标品牌
+//
+// sourceIDs 中的品牌被删除，其名称与别名成为目标品牌的别名；variants 为需要归入目标品牌的写法。
+// 之后商品与到货记录中匹配目标品牌名称或任一别名的品牌文字都改写为目标品牌名称
+func MergeBrands(targetID int, sourceIDs []int, variants []string) (*BrandMergeResult, error) {
+	target, err := GetBrandByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	sources := []int{}
+	for _, id := range sourceIDs {
+		if id == targetID {
+			continue
+		}
+		source, err := GetBrandByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			continue
+		}
+		sources = append(sources, id)
+		variants = append(variants, source.Name)
+		variants = append(variants, source.Aliases...)
+	}
+	target.Aliases = append(target.Aliases, variants...)
+	cleanBrand(target)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkBrandConflict(tx, target.Aliases, append(sources, targetID)); err != nil {
+		return nil, err
+	}
+	for _, id := range sources {
+		if _, err := tx.Exec(`DELETE FROM cc_brand WHERE id=?`, id); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM cc_brand_alias WHERE brand_id=?`, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := saveBrandAliases(tx, targetID, target.Aliases); err != nil {
+		return nil, err
+	}
+
+	// 比较不区分大小写，只有写法不同的记录计入改写数
+	texts := append([]string{target.Name}, target.Aliases...)
+	args := make([]interface{}, 0, len(texts)+1)
+	args = append(args, target.Name)
+	for _, text := range texts {
+		args = append(args, text)
+	}
+	where := fmt.Sprintf(`WHERE TRIM(brand) IN (%s)`, placeholders(len(texts)))
+
+	result := &BrandMergeResult{Brand: target}
+	res, err := tx.Exec(`UPDATE cc_product SET brand=? `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	result.Products, _ = res.RowsAffected()
+
+	res, err = tx.Exec(`UPDATE cc_arrival SET brand=? `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	result.Arrivals, _ = res.RowsAffected()
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,331 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sorting-system/database"
+	"strings"
+)
+
+// 评论对象类型
+const (
+	CommentTargetProduct = "product"
+	CommentTargetArrival = "arrival"
+)
+
+// Comment 商品或到货记录上的评论，ParentID 为 0 表示顶层评论
+type Comment struct {
+	ID         int        `json:"id"`
+	TargetType string     `json:"target_type"`
+	TargetID   int        `json:"target_id"`
+	ParentID   int        `json:"parent_id"`
+	UserID     int        `json:"user_id"`
+	UserName   string     `json:"user_name"`
+	Content    string     `json:"content"`
+	Images     []string   `json:"images"`
+	Mentions   []int      `json:"mentions"`
+	CreatedAt  string     `json:"created_at"`
+	Replies    []*Comment `json:"replies,omitempty"`
+}
+
+// ErrParentNotFound 回复的评论不存在或不属于同一对象
+var ErrParentNotFound = errors.New("回复的评论不存在")
+
+// mentionRe 匹配 @用户名，用户名到空白或标点为止
+var mentionRe = regexp.MustCompile(`@([\p{L}\p{N}_.-]+)`)
+
+const commentColumns = `c.id, c.target_type, c.target_id, c.parent_id, c.user_id, COALESCE(u.name, ''),
+		c.content, c.images, c.created_at`
+
+func scanComment(row rowScanner) (*Comment, error) {
+	cm := &Comment{}
+	var images string
+	err := row.Scan(&cm.ID, &cm.TargetType, &cm.TargetID, &cm.ParentID, &cm.UserID, &cm.UserName,
+		&cm.Content, &images, &cm.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	cm.Images = []string{}
+	if images != "" {
+		if err := json.Unmarshal([]byte(images), &cm.Images); err != nil {
+			return nil, err
+		}
+	}
+	cm.Mentions = []int{}
+	return cm, nil
+}
+
+// parseMentions 从内容中解析 @用户名 并查出对应用户ID
+func parseMentions(content string) ([]int, error) {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, m := range mentionRe.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	if len(names) == 0 {
+		return []int{}, nil
+	}
+
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	return queryIDs(fmt.Sprintf("SELECT id FROM cc_user WHERE name IN (%s)", placeholders(len(names))), args...)
+}
+
+// CreateComment 发表评论并记录 @提及
+func CreateComment(cm *Comment) error {
+	if cm.Images == nil {
+		cm.Images = []string{}
+	}
+	images, err := json.Marshal(cm.Images)
+	if err != nil {
+		return err
+	}
+
+	// 回复只能挂在同一对象的评论下，且统一挂到顶层评论
+	if cm.ParentID > 0 {
+		parent, err := GetCommentByID(cm.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent == nil || parent.TargetType != cm.TargetType || parent.TargetID != cm.TargetID {
+			return ErrParentNotFound
+		}
+		if parent.ParentID > 0 {
+			cm.ParentID = parent.ParentID
+		}
+	}
+
+	mentions, err := parseMentions(cm.Content)
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO cc_comment (target_type, target_id, parent_id, user_id, content, images)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		cm.TargetType, cm.TargetID, cm.ParentID, cm.UserID, cm.Content, string(images),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	cm.ID = int(id)
+
+	cm.Mentions = []int{}
+	for _, uid := range mentions {
+		if uid == cm.UserID {
+			continue
+		}
+		if _, err := tx.Exec(
+			`INSERT IGNORE INTO cc_comment_mention (comment_id, user_id) VALUES (?, ?)`,
+			cm.ID, uid,
+		); err != nil {
+			return err
+		}
+		cm.Mentions = append(cm.Mentions, uid)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteComment 删除自己的评论及其回复
+func DeleteComment(id, userID int) error {
+	cm, err := GetCommentByID(id)
+	if err != nil {
+		return err
+	}
+	if cm == nil || cm.UserID != userID {
+		return nil
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`DELETE FROM cc_comment_mention
+		WHERE comment_id=? OR comment_id IN (SELECT id FROM cc_comment WHERE parent_id=?)`,
+		id, id,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM cc_comment WHERE id=? OR parent_id=?`, id, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetCommentByID 根据ID获取评论
+func GetCommentByID(id int) (*Comment, error) {
+	cm, err := scanComment(database.DB.QueryRow(
+		`SELECT `+commentColumns+` FROM cc_comment c LEFT JOIN cc_user u ON u.id = c.user_id WHERE c.id=?`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// GetCommentThreads 获取对象上的评论，按顶层评论组织成线程
+func GetCommentThreads(targetType string, targetID int) ([]*Comment, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+commentColumns+`
+		FROM cc_comment c LEFT JOIN cc_user u ON u.id = c.user_id
+		WHERE c.target_type=? AND c.target_id=?
+		ORDER BY c.id ASC`,
+		targetType, targetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := []*Comment{}
+	byID := map[int]*Comment{}
+	for rows.Next() {
+		cm, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, cm)
+		byID[cm.ID] = cm
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachMentions(byID); err != nil {
+		return nil, err
+	}
+
+	threads := make([]*Comment, 0)
+	for _, cm := range all {
+		if parent, ok := byID[cm.ParentID]; ok && cm.ParentID > 0 {
+			parent.Replies = append(parent.Replies, cm)
+			continue
+		}
+		threads = append(threads, cm)
+	}
+	return threads, nil
+}
+
+// GetUnreadMentions 获取提及用户且未读的评论
+func GetUnreadMentions(userID int) ([]*Comment, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+commentColumns+`
+		FROM cc_comment_mention m
+		JOIN cc_comment c ON c.id = m.comment_id
+		LEFT JOIN cc_user u ON u.id = c.user_id
+		WHERE m.user_id=? AND m.read_at IS NULL
+		ORDER BY c.id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Comment, 0)
+	for rows.Next() {
+		cm, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, cm)
+	}
+	return list, rows.Err()
+}
+
+// MarkMentionsRead 将提及标记为已读，commentIDs 为空时全部标记
+func MarkMentionsRead(userID int, commentIDs []int) error {
+	query := `UPDATE cc_comment_mention SET read_at=NOW() WHERE user_id=? AND read_at IS NULL`
+	args := []interface{}{userID}
+	if len(commentIDs) > 0 {
+		query += fmt.Sprintf(" AND comment_id IN (%s)", placeholders(len(commentIDs)))
+		for _, id := range commentIDs {
+			args = append(args, id)
+		}
+	}
+	_, err := database.DB.Exec(query, args...)
+	return err
+}
+
+// attachMentions 为评论加载被提及的用户ID
+func attachMentions(byID map[int]*Comment) error {
+	if len(byID) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(byID))
+	args := make([]interface{}, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, "?")
+		args = append(args, id)
+	}
+
+	rows, err := database.DB.Query(
+		fmt.Sprintf("SELECT comment_id, user_id FROM cc_comment_mention WHERE comment_id IN (%s)", strings.Join(ids, ",")),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID, userID int
+		if err := rows.Scan(&commentID, &userID); err != nil {
+			return err
+		}
+		if cm, ok := byID[commentID]; ok {
+			cm.Mentions = append(cm.Mentions, userID)
+		}
+	}
+	return rows.Err()
+}
+
+// deleteTargetComments 删除对象上的评论（含回复）及其提及，随商品或到货记录一起删除
+func deleteTargetComments(db sqlRunner, targetType string, targetIDs []int) error {
+	if len(targetIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(targetIDs)+1)
+	args = append(args, targetType)
+	for _, id := range targetIDs {
+		args = append(args, id)
+	}
+	if _, err := db.Exec(
+		fmt.Sprintf(`DELETE FROM cc_comment_mention WHERE comment_id IN
+		(SELECT id FROM cc_comment WHERE target_type=? AND target_id IN (%s))`, placeholders(len(targetIDs))),
+		args...,
+	); err != nil {
+		return err
+	}
+	_, err := db.Exec(
+		fmt.Sprintf(`DELETE FROM cc_comment WHERE target_type=? AND target_id IN (%s)`, placeholders(len(targetIDs))),
+		args...,
+	)
+	return err
+}
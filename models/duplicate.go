@@ -0,0 +1,247 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sorting-system/config"
+	"sorting-system/database"
+	"strings"
+	"time"
+)
+
+const (
+	DuplicateReasonSameOrder = "same_order" // 同客户、同品牌、同尺码
+	DuplicateReasonSamePhoto = "same_photo" // 照片内容相同
+)
+
+// DuplicateWarning 创建商品时的疑似重复提示
+type DuplicateWarning struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	IDs     []int  `json:"ids"`
+}
+
+// DuplicateCluster 疑似重复的商品分组
+type DuplicateCluster struct {
+	Reason string     `json:"reason"`
+	Key    string     `json:"key"`
+	IDs    []int      `json:"ids"`
+	List   []*Product `json:"list"`
+}
+
+// duplicateWindowDays 重复检测的天数窗口，未配置时默认7天
+func duplicateWindowDays() int {
+	if config.GlobalConfig != nil && config.GlobalConfig.Duplicate.WindowDays > 0 {
+		return config.GlobalConfig.Duplicate.WindowDays
+	}
+	return 7
+}
+
+// photoHash 计算上传图片的内容哈希，非本地上传或读取失败时返回空
+func photoHash(url string) string {
+	if !strings.HasPrefix(url, "/uploads/") || config.GlobalConfig == nil {
+		return ""
+	}
+
+	path := filepath.Join(config.GlobalConfig.Upload.Path, filepath.Base(url))
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FindDuplicateWarnings 查找与商品疑似重复的其他商品
+func FindDuplicateWarnings(p *Product) ([]*DuplicateWarning, error) {
+	warnings := []*DuplicateWarning{}
+
+	if p.CustomerName != "" {
+		ids, err := queryIDs(
+			`SELECT id FROM cc_product
+			WHERE id<>? AND customer_name=? AND brand=? AND size=?
+			AND created_at >= DATE_SUB(NOW(), INTERVAL ? DAY)
+			ORDER BY id ASC`,
+			p.ID, p.CustomerName, p.Brand, p.Size, duplicateWindowDays(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			warnings = append(warnings, &DuplicateWarning{
+				Reason:  DuplicateReasonSameOrder,
+				Message: fmt.Sprintf("%d天内已有相同客户、品牌和尺码的商品", duplicateWindowDays()),
+				IDs:     ids,
+			})
+		}
+	}
+
+	if p.PhotoHash != "" {
+		ids, err := queryIDs(
+			`SELECT id FROM cc_product WHERE id<>? AND photo_hash=? ORDER BY id ASC`,
+			p.ID, p.PhotoHash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			warnings = append(warnings, &DuplicateWarning{
+				Reason:  DuplicateReasonSamePhoto,
+				Message: "已有照片完全相同的商品",
+				IDs:     ids,
+			})
+		}
+	}
+
+	return warnings, nil
+}
+
+// ScanDuplicateClusters 扫描时间范围内的疑似重复商品分组
+func ScanDuplicateClusters(userID int, startTime, endTime string) ([]*DuplicateCluster, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	if startTime != "" {
+		whereClause += " AND created_at >= ?"
+		args = append(args, startTime)
+	}
+	if endTime != "" {
+		whereClause += " AND created_at <= ?"
+		args = append(args, endTime)
+	}
+
+	rows, err := database.DB.Query(
+		fmt.Sprintf("SELECT %s FROM cc_product %s ORDER BY created_at ASC, id ASC", productColumns, whereClause),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byOrder := map[string][]*Product{}
+	byPhoto := map[string][]*Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		maskProductCost(p, userID)
+		if p.CustomerName != "" {
+			key := p.CustomerName + " / " + p.Brand + " / " + p.Size
+			byOrder[key] = append(byOrder[key], p)
+		}
+		if p.PhotoHash != "" {
+			byPhoto[p.PhotoHash] = append(byPhoto[p.PhotoHash], p)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	clusters := []*DuplicateCluster{}
+	window := time.Duration(duplicateWindowDays()) * 24 * time.Hour
+	for key, list := range byOrder {
+		for _, group := range splitByWindow(list, window) {
+			clusters = append(clusters, newDuplicateCluster(DuplicateReasonSameOrder, key, group))
+		}
+	}
+	for key, list := range byPhoto {
+		if len(list) > 1 {
+			clusters = append(clusters, newDuplicateCluster(DuplicateReasonSamePhoto, key, list))
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].IDs[0] < clusters[j].IDs[0]
+	})
+	return clusters, nil
+}
+
+// splitByWindow 将按创建时间排序的商品切分为相邻间隔不超过窗口的分组，只保留多于一条的分组
+func splitByWindow(list []*Product, window time.Duration) [][]*Product {
+	groups := [][]*Product{}
+	current := []*Product{}
+	var last time.Time
+	for _, p := range list {
+		t, err := time.Parse("2006-01-02T15:04:05Z07:00", p.CreatedAt)
+		if err != nil {
+			t, _ = time.Parse("2006-01-02 15:04:05", p.CreatedAt)
+		}
+		if len(current) > 0 && t.Sub(last) > window {
+			if len(current) > 1 {
+				groups = append(groups, current)
+			}
+			current = []*Product{}
+		}
+		current = append(current, p)
+		last = t
+	}
+	if len(current) > 1 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+func newDuplicateCluster(reason, key string, list []*Product) *DuplicateCluster {
+	ids := make([]int, len(list))
+	for i, p := range list {
+		ids[i] = p.ID
+	}
+	sort.Ints(ids)
+	return &DuplicateCluster{Reason: reason, Key: key, IDs: ids, List: list}
+}
+
+// queryIDs 执行只返回ID列的查询
+func queryIDs(query string, args ...interface{}) ([]int, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// missingIDs 返回 ids 中在表内不存在的ID，保持原顺序
+func missingIDs(table string, ids []int) ([]int, error) {
+	missing := []int{}
+	if len(ids) == 0 {
+		return missing, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	found, err := queryIDs(fmt.Sprintf("SELECT id FROM %s WHERE id IN (%s)", table, placeholders(len(ids))), args...)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[int]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+	for _, id := range ids {
+		if !exists[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sorting-system/config"
+	"sorting-system/database"
+)
+
+// FreightEstimate 运费估算结果
+type FreightEstimate struct {
+	Route            string  `json:"route"`
+	Weight           float64 `json:"weight"`
+	VolumetricWeight float64 `json:"volumetric_weight"`
+	ChargeableWeight float64 `json:"chargeable_weight"`
+	PerKg            float64 `json:"per_kg"`
+	Fee              float64 `json:"fee"`
+}
+
+// volumetricDivisor 体积重除数，未配置时默认6000
+func volumetricDivisor() float64 {
+	if config.GlobalConfig != nil && config.GlobalConfig.Shipping.VolumetricDivisor > 0 {
+		return config.GlobalConfig.Shipping.VolumetricDivisor
+	}
+	return 6000
+}
+
+// VolumetricWeight 按长宽高（cm）计算体积重（kg），保留3位小数
+func VolumetricWeight(length, width, height float64) float64 {
+	if length <= 0 || width <= 0 || height <= 0 {
+		return 0
+	}
+	return math.Round(length*width*height/volumetricDivisor()*1000) / 1000
+}
+
+// ChargeableWeight 计费重量，取实重与体积重中较大者
+func ChargeableWeight(weight, length, width, height float64) float64 {
+	return math.Max(weight, VolumetricWeight(length, width, height))
+}
+
+// RateCards 已配置的运价表
+func RateCards() []config.RateCard {
+	if config.GlobalConfig == nil || config.GlobalConfig.Shipping.Routes == nil {
+		return []config.RateCard{}
+	}
+	return config.GlobalConfig.Shipping.Routes
+}
+
+// findRateCard 按名称查找运价表，名称为空时使用默认线路
+func findRateCard(route string) (*config.RateCard, error) {
+	if route == "" && config.GlobalConfig != nil {
+		route = config.GlobalConfig.Shipping.DefaultRoute
+	}
+	cards := RateCards()
+	for i := range cards {
+		if cards[i].Name == route {
+			return &cards[i], nil
+		}
+	}
+	if route == "" {
+		return nil, fmt.Errorf("未配置默认运输线路")
+	}
+	return nil, fmt.Errorf("未配置运输线路: %s", route)
+}
+
+// EstimateFreight 按线路运价表估算运费，重量为0时运费为0
+func EstimateFreight(route string, weight, length, width, height float64) (*FreightEstimate, error) {
+	card, err := findRateCard(route)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &FreightEstimate{
+		Route:            card.Name,
+		Weight:           weight,
+		VolumetricWeight: VolumetricWeight(length, width, height),
+		ChargeableWeight: ChargeableWeight(weight, length, width, height),
+	}
+	if e.ChargeableWeight <= 0 {
+		return e, nil
+	}
+
+	for _, tier := range card.Tiers {
+		e.PerKg = tier.PerKg
+		if tier.UpToKg == 0 || e.ChargeableWeight <= tier.UpToKg {
+			break
+		}
+	}
+	e.Fee = math.Max(math.Round(e.ChargeableWeight*e.PerKg*100)/100, card.MinCharge)
+	return e, nil
+}
+
+// applyShippingEstimate 运费未确认时按重量和尺寸估算 shipping_fee
+//
+// 没有重量或线路未配置时保留原运费
+func applyShippingEstimate(p *Product) {
+	p.VolumetricWeight = VolumetricWeight(p.Length, p.Width, p.Height)
+	p.ChargeableWeight = ChargeableWeight(p.Weight, p.Length, p.Width, p.Height)
+	if p.ShippingConfirmed || p.ChargeableWeight <= 0 {
+		return
+	}
+	e, err := EstimateFreight(p.Route, p.Weight, p.Length, p.Width, p.Height)
+	if err != nil {
+		return
+	}
+	p.ShippingFee = e.Fee
+}
+
+// ConfirmShippingFees 确认商品当前运费，之后不再自动估算
+func ConfirmShippingFees(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	_, err := database.DB.Exec(
+		fmt.Sprintf("UPDATE cc_product SET shipping_confirmed=1 WHERE id IN (%s)", placeholders(len(ids))),
+		args...,
+	)
+	return err
+}
+
+// ReestimateShippingFees 取消运费确认并按重量、尺寸重新估算
+func ReestimateShippingFees(ids []int, userID int) error {
+	if err := checkProductsOpen(ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := database.DB.Exec(`UPDATE cc_product SET shipping_confirmed=0 WHERE id=?`, id); err != nil {
+			return err
+		}
+		p, err := GetProductByID(id, userID)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			continue
+		}
+		if err := UpdateProduct(p, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
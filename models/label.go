@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"sorting-system/database"
+	"strconv"
+	"strings"
+)
+
+// MaxLabels 单次最多打印的标签数
+const MaxLabels = 1000
+
+// ProductCodePrefix 标签条码中商品ID的前缀，与纯数字的箱号、运单号区分
+const ProductCodePrefix = "P"
+
+// ErrTooManyLabels 符合条件的商品超过 MaxLabels
+var ErrTooManyLabels = fmt.Errorf("一次最多打印%d张标签，请缩小范围", MaxLabels)
+
+// Label 分拣标签内容
+type Label struct {
+	ProductID    int    `json:"product_id"`
+	CustomerName string `json:"customer_name"`
+	AreaName     string `json:"area_name"`
+	Brand        string `json:"brand"`
+	Size         string `json:"size"`
+	Quantity     int    `json:"quantity"`
+}
+
+// Code 条码与二维码内容，即带前缀的商品ID，如 P123
+func (l *Label) Code() string {
+	return ProductCodePrefix + strconv.Itoa(l.ProductID)
+}
+
+// ParseProductCode 解析标签上的商品条码，不带前缀的编码不视为商品ID
+func ParseProductCode(code string) (int, bool) {
+	rest := strings.TrimPrefix(strings.ToUpper(code), ProductCodePrefix)
+	if len(rest) == len(code) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(rest)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// GetLabels 获取符合条件商品的标签内容，按区域、客户排序便于整区打印后分拣
+func GetLabels(whereClause string, args []interface{}) ([]*Label, error) {
+	query := fmt.Sprintf(`SELECT id, COALESCE(area_id, 0), COALESCE(customer_name, ''), COALESCE(brand, ''),
+		COALESCE(size, ''), COALESCE(quantity, 0)
+		FROM cc_product %s
+		ORDER BY area_id ASC, customer_name ASC, id ASC
+		LIMIT %d`, whereClause, MaxLabels+1)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Label, 0)
+	areaIDs := make([]int, 0)
+	for rows.Next() {
+		l := &Label{}
+		var areaID int
+		if err := rows.Scan(&l.ProductID, &areaID, &l.CustomerName, &l.Brand, &l.Size, &l.Quantity); err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+		areaIDs = append(areaIDs, areaID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list) > MaxLabels {
+		return nil, ErrTooManyLabels
+	}
+
+	names, err := areaNameMap()
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range list {
+		l.AreaName = names[strconv.Itoa(areaIDs[i])]
+	}
+	return list, nil
+}
@@ -0,0 +1,648 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sorting-system/database"
+	"strings"
+)
+
+// SplitPart 拆分后的一行商品；按件数拆分时 Quantity 为件数，Size 为空时按原尺码改写件数
+type SplitPart struct {
+	Quantity int    `json:"quantity"`
+	Size     string `json:"size"`
+	AreaID   *int   `json:"area_id"`
+}
+
+// ProductHistory 商品拆分与合并的记录，Snapshot 为操作前的商品
+type ProductHistory struct {
+	ID        int             `json:"id"`
+	Action    string          `json:"action"`
+	UserID    int             `json:"user_id"`
+	FromIDs   []int           `json:"from_ids"`
+	ToIDs     []int           `json:"to_ids"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+	CreatedAt string          `json:"created_at"`
+}
+
+const (
+	HistorySplit = "split"
+	HistoryMerge = "merge"
+)
+
+var (
+	sizeQuantityPattern = regexp.MustCompile(`(\d+)\s*([件个條条])`)
+	sizeOnlyQuantity    = regexp.MustCompile(`^\s*\d+\s*[件个條条]\s*$`)
+	sizeLineSeparators  = regexp.MustCompile(`[\n\r,，;；、/]+`)
+)
+
+// splitSizeLines 按换行、逗号、分号、顿号和斜杠拆分尺码
+func splitSizeLines(size string) []string {
+	lines := []string{}
+	for _, line := range sizeLineSeparators.Split(size, -1) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// sizeWithQuantity 将尺码中的件数改为 n，没有件数时返回 "n件"
+func sizeWithQuantity(size string, n int) string {
+	if loc := sizeQuantityPattern.FindStringSubmatchIndex(size); loc != nil {
+		return size[:loc[2]] + fmt.Sprint(n) + size[loc[3]:]
+	}
+	return fmt.Sprintf("%d件", n)
+}
+
+// splitWeight 按基数拆分重量，保留3位小数，余数计入最后一行
+func splitWeight(total float64, bases []float64) []float64 {
+	sum := 0.0
+	for _, b := range bases {
+		sum += b
+	}
+	shares := make([]float64, len(bases))
+	assigned := 0.0
+	for i, b := range bases {
+		if i == len(bases)-1 {
+			shares[i] = math.Round((total-assigned)*1000) / 1000
+			break
+		}
+		shares[i] = math.Round(total*b/sum*1000) / 1000
+		assigned += shares[i]
+	}
+	return shares
+}
+
+// recordHistory 记录拆分或合并及商品的来源关系
+func recordHistory(tx *sql.Tx, action string, userID int, snapshot []*Product, fromIDs, toIDs []int) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(
+		`INSERT INTO cc_product_history (action, user_id, snapshot) VALUES (?, ?, ?)`,
+		action, userID, string(data),
+	)
+	if err != nil {
+		return err
+	}
+	historyID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for _, from := range fromIDs {
+		for _, to := range toIDs {
+			if _, err := tx.Exec(
+				`INSERT INTO cc_product_lineage (history_id, from_id, to_id) VALUES (?, ?, ?)`,
+				historyID, from, to,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SplitProduct 将一行商品拆分为多行，成本、售价、运费和重量按件数比例分摊
+//
+// by 为 quantity 时按 parts 的件数拆分，parts 为空时拆为每行1件；为 size 时按尺码的每一行拆分，
+// parts 只用于指定各行的区域。第一行沿用原商品ID及其评论、附件、扫码记录，
+// 其余为新商品并复制标签以及到货、运单、拣货单和包裹关联；关联商品的收款按售价比例拆分到各行
+func SplitProduct(id, userID int, by string, parts []SplitPart) ([]*Product, error) {
+	p, err := GetProductByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 非管理员只能拆分自己录入的商品
+	if p == nil || (userID != 1 && p.UserID != userID) {
+		return nil, sql.ErrNoRows
+	}
+	if err := checkProductsOpen([]int{id}); err != nil {
+		return nil, err
+	}
+	var returns int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM cc_product_return WHERE product_id=?`, id).Scan(&returns); err != nil {
+		return nil, err
+	}
+	if returns > 0 {
+		return nil, fmt.Errorf("有退货记录的商品不能拆分")
+	}
+
+	switch by {
+	case "size":
+		lines := splitSizeLines(p.Size)
+		if len(lines) < 2 {
+			return nil, fmt.Errorf("尺码只有一行，无法按尺码拆分")
+		}
+		sizeParts := make([]SplitPart, len(lines))
+		for i, line := range lines {
+			sizeParts[i] = SplitPart{Quantity: max(parseQuantityFromSize(line), 1), Size: line}
+			if i < len(parts) {
+				sizeParts[i].AreaID = parts[i].AreaID
+			}
+		}
+		parts = sizeParts
+	case "quantity", "":
+		if len(parts) == 0 {
+			for i := 0; i < p.Quantity; i++ {
+				parts = append(parts, SplitPart{Quantity: 1})
+			}
+		}
+		total := 0
+		for i := range parts {
+			if parts[i].Quantity <= 0 {
+				return nil, fmt.Errorf("每行件数必须大于0")
+			}
+			total += parts[i].Quantity
+			if strings.TrimSpace(parts[i].Size) == "" {
+				parts[i].Size = sizeWithQuantity(p.Size, parts[i].Quantity)
+			}
+		}
+		if total != p.Quantity {
+			return nil, fmt.Errorf("拆分件数合计 %d 与原商品件数 %d 不一致", total, p.Quantity)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的拆分方式: %s", by)
+	}
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("至少拆分为两行")
+	}
+
+	bases := make([]float64, len(parts))
+	for i, part := range parts {
+		bases[i] = float64(part.Quantity)
+	}
+	costs, err := splitAmount(p.CostEur, bases)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := splitAmount(p.PriceRMB, bases)
+	if err != nil {
+		return nil, err
+	}
+	fees, err := splitAmount(p.ShippingFee, bases)
+	if err != nil {
+		return nil, err
+	}
+	weights := splitWeight(p.Weight, bases)
+
+	// 收款按售价比例拆分，没有售价时按件数
+	paymentBases := bases
+	if p.PriceRMB > 0 {
+		paymentBases = prices
+	}
+	payments, err := splitPayments(id, paymentBases)
+	if err != nil {
+		return nil, err
+	}
+	shipmentItem, err := splitShipmentItem(id, bases)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Product, len(parts))
+	for i, part := range parts {
+		child := *p
+		child.Tags = nil
+		child.Size = strings.TrimSpace(part.Size)
+		child.Quantity = part.Quantity
+		if part.AreaID != nil {
+			child.AreaID = part.AreaID
+		}
+		child.CostEur, child.PriceRMB, child.ShippingFee, child.Weight = costs[i], prices[i], fees[i], weights[i]
+		if err := ValidateProduct(&child, p, userID); err != nil {
+			return nil, err
+		}
+		calculateProduct(&child)
+		result[i] = &child
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, len(result))
+	for i, child := range result {
+		if i == 0 {
+			if err := updateProductRow(tx, child); err != nil {
+				return nil, err
+			}
+		} else {
+			if err := insertProduct(tx, child); err != nil {
+				return nil, err
+			}
+			// 新行沿用原商品的创建时间，保持所属月份不变
+			if _, err := tx.Exec(`UPDATE cc_product SET created_at=? WHERE id=?`, sortTimeValue(p.CreatedAt), child.ID); err != nil {
+				return nil, err
+			}
+			for _, query := range splitCopies {
+				if _, err := tx.Exec(query, child.ID, p.ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+		ids[i] = child.ID
+	}
+	for _, pay := range payments {
+		if _, err := tx.Exec(`UPDATE cc_payment SET amount=? WHERE id=?`, pay.shares[0], pay.id); err != nil {
+			return nil, err
+		}
+		for i, amount := range pay.shares[1:] {
+			if amount == 0 {
+				continue
+			}
+			if _, err := tx.Exec(
+				`INSERT INTO cc_payment (user_id, product_id, customer_name, kind, method, amount, paid_at, reference, note)
+				SELECT user_id, ?, customer_name, kind, method, ?, paid_at, reference, note FROM cc_payment WHERE id=?`,
+				ids[i+1], amount, pay.id,
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if shipmentItem != nil {
+		for i, productID := range ids {
+			if _, err := tx.Exec(
+				`INSERT INTO cc_shipment_item (shipment_id, product_id, weight, ratio, allocated) VALUES (?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE weight=VALUES(weight), ratio=VALUES(ratio), allocated=VALUES(allocated)`,
+				shipmentItem.shipmentID, productID, shipmentItem.weights[i], shipmentItem.ratios[i], shipmentItem.allocated[i],
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := syncOutboundStatus(tx, ids); err != nil {
+		return nil, err
+	}
+	if err := recordHistory(tx, HistorySplit, userID, []*Product{p}, []int{p.ID}, ids); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := SyncCustomerPayments(p.CustomerName); err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		if result[i], err = GetProductByID(id, userID); err != nil {
+			return nil, err
+		}
+		maskProductCost(result[i], userID)
+	}
+	return result, nil
+}
+
+// splitCopies 拆分时新行复制原商品的标签以及到货、拣货单和包裹关联，参数为新商品ID和原商品ID
+var splitCopies = []string{
+	`INSERT IGNORE INTO cc_product_tag (product_id, tag_id) SELECT ?, tag_id FROM cc_product_tag WHERE product_id=?`,
+	`INSERT IGNORE INTO cc_arrival_item (arrival_id, product_id, user_id)
+	SELECT arrival_id, ?, user_id FROM cc_arrival_item WHERE product_id=?`,
+	`INSERT IGNORE INTO cc_pick_list_item (pick_list_id, product_id, scanned_at)
+	SELECT pick_list_id, ?, scanned_at FROM cc_pick_list_item WHERE product_id=?`,
+	`INSERT IGNORE INTO cc_parcel_item (parcel_id, product_id) SELECT parcel_id, ? FROM cc_parcel_item WHERE product_id=?`,
+}
+
+// paymentSplit 一笔关联商品的收款拆分到各行的金额
+type paymentSplit struct {
+	id     int
+	shares []float64
+}
+
+// splitPayments 按基数拆分关联到商品的收款
+func splitPayments(productID int, bases []float64) ([]paymentSplit, error) {
+	rows, err := database.DB.Query(`SELECT id, amount FROM cc_payment WHERE product_id=?`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []paymentSplit{}
+	for rows.Next() {
+		var id int
+		var amount float64
+		if err := rows.Scan(&id, &amount); err != nil {
+			return nil, err
+		}
+		shares, err := splitAmount(amount, bases)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, paymentSplit{id, shares})
+	}
+	return list, rows.Err()
+}
+
+// shipmentItemSplit 运单明细拆分到各行的重量、比例与已分摊金额
+type shipmentItemSplit struct {
+	shipmentID int
+	weights    []float64
+	ratios     []float64
+	allocated  []float64
+}
+
+// splitShipmentItem 按基数拆分商品所在的运单明细，商品不在运单中时返回 nil
+func splitShipmentItem(productID int, bases []float64) (*shipmentItemSplit, error) {
+	var shipmentID int
+	var weight, ratio, allocated float64
+	err := database.DB.QueryRow(
+		`SELECT shipment_id, weight, ratio, allocated FROM cc_shipment_item WHERE product_id=?`, productID,
+	).Scan(&shipmentID, &weight, &ratio, &allocated)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	item := &shipmentItemSplit{shipmentID: shipmentID, weights: splitWeight(weight, bases)}
+	if item.allocated, err = splitAmount(allocated, bases); err != nil {
+		return nil, err
+	}
+	sum := 0.0
+	for _, b := range bases {
+		sum += b
+	}
+	for _, b := range bases {
+		item.ratios = append(item.ratios, math.Round(ratio*b/sum*10000)/10000)
+	}
+	return item, nil
+}
+
+// mergeTexts 合并非空且不重复的文字
+func mergeTexts(values []string, sep string) string {
+	out := []string{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		dup := false
+		for _, o := range out {
+			if o == v {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, v)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// MergeProducts 将同一客户的多行商品合并到 keepID
+//
+// 成本、售价、运费、重量和件数相加，备注合并，其他商品的照片保存为保留商品的附件；
+// 到货、标签、附件、评论、收款、退货、运单、拣货单、包裹和扫码记录转移到保留的商品，其余商品删除；
+// 涉及的已分摊运单在合并后重新分摊。非管理员只能合并自己录入的商品
+func MergeProducts(keepID int, ids []int, userID int) (*Product, error) {
+	all := []int{keepID}
+	for _, id := range ids {
+		dup := false
+		for _, a := range all {
+			if a == id {
+				dup = true
+			}
+		}
+		if !dup {
+			all = append(all, id)
+		}
+	}
+	if len(all) < 2 {
+		return nil, fmt.Errorf("至少选择两行商品")
+	}
+	if err := checkProductsOpen(all); err != nil {
+		return nil, err
+	}
+
+	products := make([]*Product, len(all))
+	for i, id := range all {
+		p, err := GetProductByID(id, userID)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil || (userID != 1 && p.UserID != userID) {
+			return nil, sql.ErrNoRows
+		}
+		if i > 0 && p.CustomerName != products[0].CustomerName {
+			return nil, fmt.Errorf("只能合并同一客户的商品")
+		}
+		products[i] = p
+	}
+
+	keep := products[0]
+	merged := *keep
+	merged.Tags = nil
+	merged.CostEur, merged.PriceRMB, merged.ShippingFee, merged.Weight, merged.Quantity = 0, 0, 0, 0, 0
+	merged.RefundedAmount, merged.ReturnAdjustment = 0, 0
+	merged.ShippingConfirmed = false
+	costRMB := 0.0
+	sizes, marks := []string{}, []string{}
+	type mergedPhoto struct {
+		kind, url string
+		from      int
+	}
+	photos := []mergedPhoto{}
+	onlyQuantities := true
+	for _, p := range products {
+		merged.CostEur += p.CostEur
+		costRMB += p.CostEur * p.ExchangeRate
+		merged.PriceRMB += p.PriceRMB
+		merged.ShippingFee += p.ShippingFee
+		merged.ShippingConfirmed = merged.ShippingConfirmed || p.ShippingConfirmed
+		merged.Weight += p.Weight
+		merged.Quantity += p.Quantity
+		merged.RefundedAmount += p.RefundedAmount
+		merged.ReturnAdjustment += p.ReturnAdjustment
+		sizes = append(sizes, p.Size)
+		marks = append(marks, p.Mark)
+		onlyQuantities = onlyQuantities && sizeOnlyQuantity.MatchString(p.Size)
+		if merged.Photo == "" {
+			merged.Photo = p.Photo
+		} else if p.Photo != "" && p.Photo != merged.Photo {
+			photos = append(photos, mergedPhoto{"product", p.Photo, p.ID})
+		}
+		if merged.StatusNotePhoto == "" {
+			merged.StatusNotePhoto = p.StatusNotePhoto
+		} else if p.StatusNotePhoto != "" && p.StatusNotePhoto != merged.StatusNotePhoto {
+			photos = append(photos, mergedPhoto{"status", p.StatusNotePhoto, p.ID})
+		}
+		if merged.Address == "" {
+			merged.Address = p.Address
+		}
+		if merged.Brand == "" {
+			merged.Brand = p.Brand
+		}
+	}
+	if merged.CostEur > 0 {
+		merged.ExchangeRate = math.Round(costRMB/merged.CostEur*10000) / 10000
+	}
+	if onlyQuantities {
+		merged.Size = fmt.Sprintf("%d件", merged.Quantity)
+	} else {
+		merged.Size = mergeTexts(sizes, "；")
+	}
+	merged.Mark = mergeTexts(marks, "；")
+	if err := ValidateProduct(&merged, keep, userID); err != nil {
+		return nil, err
+	}
+	applyShippingEstimate(&merged)
+	calculateProduct(&merged)
+	merged.PhotoHash = photoHash(merged.Photo)
+
+	// 合并可能删除运单明细（每件商品只能在一个运单中），合并后重新分摊
+	shipments, err := allocatedShipmentsOf(all)
+	if err != nil {
+		return nil, err
+	}
+
+	removeIDs := all[1:]
+	args := make([]interface{}, 0, len(removeIDs)+1)
+	args = append(args, keepID)
+	for _, id := range removeIDs {
+		args = append(args, id)
+	}
+	in := placeholders(len(removeIDs))
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := updateProductRow(tx, &merged); err != nil {
+		return nil, err
+	}
+
+	moves := []string{
+		`UPDATE IGNORE cc_arrival_item SET product_id=? WHERE product_id IN (%s)`,
+		`INSERT IGNORE INTO cc_product_tag (product_id, tag_id) SELECT ?, tag_id FROM cc_product_tag WHERE product_id IN (%s)`,
+		`UPDATE cc_product_attachment SET product_id=? WHERE product_id IN (%s)`,
+		`UPDATE cc_comment SET target_id=? WHERE target_type='product' AND target_id IN (%s)`,
+		`UPDATE cc_payment SET product_id=? WHERE product_id IN (%s)`,
+		`UPDATE cc_product_return SET product_id=? WHERE product_id IN (%s)`,
+		`UPDATE IGNORE cc_shipment_item SET product_id=? WHERE product_id IN (%s)`,
+		`UPDATE IGNORE cc_pick_list_item SET product_id=? WHERE product_id IN (%s)`,
+		`UPDATE IGNORE cc_parcel_item SET product_id=? WHERE product_id IN (%s)`,
+		`UPDATE cc_scan_event SET product_id=? WHERE product_id IN (%s)`,
+	}
+	for _, query := range moves {
+		if _, err := tx.Exec(fmt.Sprintf(query, in), args...); err != nil {
+			return nil, err
+		}
+	}
+	// 保留商品已有关联时未能转移的记录随商品一起删除
+	for _, table := range []string{"cc_product", "cc_arrival_item", "cc_product_tag", "cc_shipment_item", "cc_pick_list_item", "cc_parcel_item"} {
+		column := "product_id"
+		if table == "cc_product" {
+			column = "id"
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s IN (%s)`, table, column, in), args[1:]...); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, photo := range photos {
+		if _, err := tx.Exec(
+			`INSERT INTO cc_product_attachment (product_id, user_id, kind, url, caption, sort_order) VALUES (?, ?, ?, ?, ?, ?)`,
+			keepID, userID, photo.kind, photo.url, fmt.Sprintf("合并自 #%d", photo.from), 1000+i,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := syncOutboundStatus(tx, []int{keepID}); err != nil {
+		return nil, err
+	}
+	if err := recordHistory(tx, HistoryMerge, userID, products, all, []int{keepID}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// 重算退货金额并同步客户收款
+	if err := applyReturns(keepID); err != nil {
+		return nil, err
+	}
+	if err := reallocateShipments(shipments); err != nil {
+		return nil, err
+	}
+	return GetProductByID(keepID, userID)
+}
+
+// GetProductHistory 商品作为来源或结果参与的拆分与合并记录，最新的在前
+func GetProductHistory(productID int) ([]*ProductHistory, error) {
+	rows, err := database.DB.Query(
+		`SELECT h.id, h.action, h.user_id, h.snapshot, h.created_at FROM cc_product_history h
+		WHERE h.id IN (SELECT history_id FROM cc_product_lineage WHERE from_id=? OR to_id=?)
+		ORDER BY h.id DESC`,
+		productID, productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ProductHistory, 0)
+	byID := map[int]*ProductHistory{}
+	for rows.Next() {
+		h := &ProductHistory{FromIDs: []int{}, ToIDs: []int{}}
+		var snapshot string
+		if err := rows.Scan(&h.ID, &h.Action, &h.UserID, &snapshot, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		h.Snapshot = json.RawMessage(snapshot)
+		h.CreatedAt = sortTimeValue(h.CreatedAt)
+		list = append(list, h)
+		byID[h.ID] = h
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	args := make([]interface{}, 0, len(list))
+	for _, h := range list {
+		args = append(args, h.ID)
+	}
+	lineage, err := database.DB.Query(
+		fmt.Sprintf(`SELECT history_id, from_id, to_id FROM cc_product_lineage WHERE history_id IN (%s) ORDER BY id ASC`,
+			placeholders(len(args))),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lineage.Close()
+
+	for lineage.Next() {
+		var historyID, from, to int
+		if err := lineage.Scan(&historyID, &from, &to); err != nil {
+			return nil, err
+		}
+		h := byID[historyID]
+		h.FromIDs = appendUnique(h.FromIDs, from)
+		h.ToIDs = appendUnique(h.ToIDs, to)
+	}
+	return list, lineage.Err()
+}
+
+// appendUnique 不重复地追加ID
+func appendUnique(ids []int, id int) []int {
+	for _, v := range ids {
+		if v == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
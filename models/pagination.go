@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCursor 游标无法解析或与当前排序不一致
+var ErrInvalidCursor = errors.New("无效的分页游标")
+
+// ListOptions 列表分页与排序参数
+//
+// 传入 Cursor 时使用游标分页（按排序列+ID定位），忽略 Page，且不再统计总数和汇总；
+// 首页可通过 SkipTotal 跳过总数统计
+type ListOptions struct {
+	Page      int
+	PageSize  int
+	OrderBy   string
+	OrderDir  string
+	Cursor    string
+	SkipTotal bool
+}
+
+// cursor 游标内容：排序列、方向、最后一行的排序值和ID
+type cursor struct {
+	OrderBy  string      `json:"o"`
+	OrderDir string      `json:"d"`
+	Value    interface{} `json:"v"`
+	ID       int         `json:"id"`
+}
+
+func encodeCursor(orderBy, orderDir string, value interface{}, id int) string {
+	data, _ := json.Marshal(cursor{OrderBy: orderBy, OrderDir: orderDir, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s, orderBy, orderDir string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(raw, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.OrderBy != orderBy || c.OrderDir != orderDir {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// keysetClause 生成位于游标之后的行的条件，expr 为排序表达式
+func keysetClause(expr string, c *cursor) (string, []interface{}) {
+	op := "<"
+	if c.OrderDir == "ASC" {
+		op = ">"
+	}
+	return fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", expr, op, expr, op),
+		[]interface{}{c.Value, c.Value, c.ID}
+}
+
+// sortTimeValue 将扫描出的时间字符串转为 MySQL 可比较的格式
+func sortTimeValue(s string) string {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(time.Local).Format("2006-01-02 15:04:05")
+	}
+	return s
+}
@@ -0,0 +1,395 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sorting-system/database"
+	"sorting-system/validate"
+	"strings"
+)
+
+// 发货包裹状态，商品的 outbound_status 与所在包裹一致
+const (
+	ParcelPacked    = "packed"
+	ParcelShipped   = "shipped"
+	ParcelDelivered = "delivered"
+)
+
+// ValidParcelStatuses 支持的包裹状态
+var ValidParcelStatuses = map[string]bool{
+	ParcelPacked:    true,
+	ParcelShipped:   true,
+	ParcelDelivered: true,
+}
+
+// Parcel 分拣后寄给客户的国内快递包裹
+type Parcel struct {
+	ID          int           `json:"id"`
+	UserID      int           `json:"user_id"`
+	Carrier     string        `json:"carrier"`
+	TrackingNo  string        `json:"tracking_no"`
+	Recipient   string        `json:"recipient"`
+	Address     string        `json:"address"`
+	Weight      float64       `json:"weight"`
+	Cost        float64       `json:"cost"`
+	Status      string        `json:"status"`
+	ShippedDate *string       `json:"shipped_date"`
+	Note        string        `json:"note"`
+	ItemCount   int           `json:"item_count"`
+	CreatedAt   string        `json:"created_at"`
+	UpdatedAt   string        `json:"updated_at"`
+	Items       []*ParcelItem `json:"items,omitempty"`
+}
+
+// ParcelItem 包裹中的商品
+type ParcelItem struct {
+	ParcelID     int    `json:"parcel_id"`
+	ProductID    int    `json:"product_id"`
+	CustomerName string `json:"customer_name"`
+	Brand        string `json:"brand"`
+	Size         string `json:"size"`
+	Quantity     int    `json:"quantity"`
+	Photo        string `json:"photo"`
+}
+
+// ParcelSchema 包裹可写字段的校验规则
+var ParcelSchema = validate.Schema{
+	"carrier":      {MaxLen: 50, Label: "快递公司"},
+	"tracking_no":  {MaxLen: 100, Label: "快递单号"},
+	"recipient":    {MaxLen: 200, Label: "收件人"},
+	"address":      {Label: "收件地址"},
+	"weight":       {Kind: validate.KindNumber, NonNeg: true, Label: "包裹重量"},
+	"cost":         {Kind: validate.KindNumber, NonNeg: true, Label: "快递费"},
+	"shipped_date": {Kind: validate.KindDate, Label: "发货日期"},
+	"note":         {MaxLen: 500, Label: "备注"},
+}
+
+const parcelColumns = `id, user_id, carrier, tracking_no, recipient, COALESCE(address, ''), weight, cost, status,
+	DATE_FORMAT(shipped_date, '%Y-%m-%d'), note, created_at, updated_at,
+	(SELECT COUNT(*) FROM cc_parcel_item WHERE parcel_id = cc_parcel.id)`
+
+func scanParcel(row rowScanner) (*Parcel, error) {
+	p := &Parcel{}
+	var shippedDate sql.NullString
+	err := row.Scan(&p.ID, &p.UserID, &p.Carrier, &p.TrackingNo, &p.Recipient, &p.Address, &p.Weight, &p.Cost,
+		&p.Status, &shippedDate, &p.Note, &p.CreatedAt, &p.UpdatedAt, &p.ItemCount)
+	if err != nil {
+		return nil, err
+	}
+	if shippedDate.Valid {
+		p.ShippedDate = &shippedDate.String
+	}
+	return p, nil
+}
+
+// NormalizeTrackingNo 去掉快递单号中的空白并转为大写，保存和查询时使用同一格式
+func NormalizeTrackingNo(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// prepareParcel 规范化并校验包裹字段；填写发货日期的待发货包裹视为已发货
+func prepareParcel(p *Parcel) error {
+	p.Carrier = strings.TrimSpace(p.Carrier)
+	p.TrackingNo = NormalizeTrackingNo(p.TrackingNo)
+	p.Recipient = strings.TrimSpace(p.Recipient)
+	p.Address = strings.TrimSpace(p.Address)
+	if p.ShippedDate != nil {
+		if d := strings.TrimSpace(*p.ShippedDate); d != "" {
+			p.ShippedDate = &d
+		} else {
+			p.ShippedDate = nil
+		}
+	}
+
+	shippedDate := ""
+	if p.ShippedDate != nil {
+		shippedDate = *p.ShippedDate
+	}
+	errs := checkFields(ParcelSchema, []fieldValue{
+		{"carrier", p.Carrier},
+		{"tracking_no", p.TrackingNo},
+		{"recipient", p.Recipient},
+		{"address", p.Address},
+		{"weight", p.Weight},
+		{"cost", p.Cost},
+		{"shipped_date", shippedDate},
+		{"note", p.Note},
+	})
+	if p.Status == "" {
+		p.Status = ParcelPacked
+	}
+	if !ValidParcelStatuses[p.Status] {
+		errs.Add("status", validate.CodeInvalidType, "不支持的包裹状态")
+	}
+	if p.Status == ParcelPacked && p.ShippedDate != nil {
+		p.Status = ParcelShipped
+	}
+	return errs.Err()
+}
+
+// CreateParcel 创建包裹并装入商品；收件人和地址为空时取第一件商品的客户名和地址
+func CreateParcel(p *Parcel, productIDs []int) error {
+	if err := prepareParcel(p); err != nil {
+		return err
+	}
+	if len(productIDs) > 0 && (p.Recipient == "" || p.Address == "") {
+		var customerName, address string
+		err := database.DB.QueryRow(
+			`SELECT COALESCE(customer_name, ''), COALESCE(address, '') FROM cc_product WHERE id=?`,
+			productIDs[0],
+		).Scan(&customerName, &address)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if p.Recipient == "" {
+			p.Recipient = strings.TrimSpace(customerName)
+		}
+		if p.Address == "" {
+			p.Address = strings.TrimSpace(address)
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO cc_parcel (user_id, carrier, tracking_no, recipient, address, weight, cost, status, shipped_date, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.UserID, p.Carrier, p.TrackingNo, p.Recipient, p.Address, p.Weight, p.Cost, p.Status, p.ShippedDate, p.Note,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = int(id)
+
+	if err := addParcelItems(tx, p.ID, productIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateParcel 更新包裹信息，状态变化同步到包裹中的商品
+func UpdateParcel(p *Parcel) error {
+	if err := prepareParcel(p); err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE cc_parcel SET carrier=?, tracking_no=?, recipient=?, address=?, weight=?, cost=?, status=?,
+		shipped_date=?, note=? WHERE id=?`,
+		p.Carrier, p.TrackingNo, p.Recipient, p.Address, p.Weight, p.Cost, p.Status, p.ShippedDate, p.Note, p.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`UPDATE cc_product SET outbound_status=? WHERE id IN (SELECT product_id FROM cc_parcel_item WHERE parcel_id=?)`,
+		p.Status, p.ID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteParcel 删除包裹，其中的商品恢复为未装入包裹
+func DeleteParcel(id int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE cc_product SET outbound_status='' WHERE id IN (SELECT product_id FROM cc_parcel_item WHERE parcel_id=?)`,
+		id,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM cc_parcel_item WHERE parcel_id=?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM cc_parcel WHERE id=?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetParcelByID 根据ID获取包裹
+func GetParcelByID(id int) (*Parcel, error) {
+	p, err := scanParcel(database.DB.QueryRow(
+		`SELECT `+parcelColumns+` FROM cc_parcel WHERE id=?`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetParcelList 获取包裹列表，可按状态和收件人筛选
+func GetParcelList(status, recipient string) ([]*Parcel, error) {
+	query := `SELECT ` + parcelColumns + ` FROM cc_parcel WHERE 1=1`
+	args := []interface{}{}
+	if status != "" {
+		query += " AND status=?"
+		args = append(args, status)
+	}
+	if recipient = strings.TrimSpace(recipient); recipient != "" {
+		query += " AND recipient LIKE ?"
+		args = append(args, likePattern(recipient))
+	}
+	query += " ORDER BY id DESC"
+	return queryParcels(query, args...)
+}
+
+// FindParcelsByTracking 按快递单号查找包裹，忽略空格和大小写，含商品明细
+func FindParcelsByTracking(trackingNo string) ([]*Parcel, error) {
+	trackingNo = NormalizeTrackingNo(trackingNo)
+	if trackingNo == "" {
+		return []*Parcel{}, nil
+	}
+	list, err := queryParcels(`SELECT `+parcelColumns+` FROM cc_parcel WHERE tracking_no=? ORDER BY id DESC`, trackingNo)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range list {
+		if p.Items, err = GetParcelItems(p.ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// GetProductParcel 商品所在的包裹，未装入包裹时返回 nil
+func GetProductParcel(productID int) (*Parcel, error) {
+	p, err := scanParcel(database.DB.QueryRow(
+		`SELECT `+parcelColumns+` FROM cc_parcel WHERE id = (SELECT parcel_id FROM cc_parcel_item WHERE product_id=?)`,
+		productID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func queryParcels(query string, args ...interface{}) ([]*Parcel, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Parcel, 0)
+	for rows.Next() {
+		p, err := scanParcel(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// addParcelItems 将商品装入包裹并同步商品的发货状态，已在其他包裹中的商品忽略
+func addParcelItems(db sqlRunner, parcelID int, productIDs []int) error {
+	for _, productID := range productIDs {
+		if _, err := db.Exec(
+			`INSERT IGNORE INTO cc_parcel_item (parcel_id, product_id) VALUES (?, ?)`,
+			parcelID, productID,
+		); err != nil {
+			return err
+		}
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+	return syncOutboundStatus(db, productIDs)
+}
+
+// AddParcelItems 将商品装入包裹，已在其他包裹中的商品忽略
+func AddParcelItems(parcelID int, productIDs []int) error {
+	return addParcelItems(database.DB, parcelID, productIDs)
+}
+
+// RemoveParcelItems 将商品移出包裹，商品恢复为未装入包裹
+func RemoveParcelItems(parcelID int, productIDs []int) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(productIDs)+1)
+	args = append(args, parcelID)
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	_, err := database.DB.Exec(
+		fmt.Sprintf("DELETE FROM cc_parcel_item WHERE parcel_id=? AND product_id IN (%s)", placeholders(len(productIDs))),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	return syncOutboundStatus(database.DB, productIDs)
+}
+
+// syncOutboundStatus 将商品的发货状态设为所在包裹的状态，不在包裹中的设为空
+func syncOutboundStatus(db sqlRunner, productIDs []int) error {
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	_, err := db.Exec(
+		fmt.Sprintf(`UPDATE cc_product SET outbound_status = COALESCE(
+			(SELECT pa.status FROM cc_parcel_item pi JOIN cc_parcel pa ON pa.id = pi.parcel_id WHERE pi.product_id = cc_product.id),
+			'') WHERE id IN (%s)`, placeholders(len(productIDs))),
+		args...,
+	)
+	return err
+}
+
+// GetParcelItems 获取包裹中的商品
+func GetParcelItems(parcelID int) ([]*ParcelItem, error) {
+	rows, err := database.DB.Query(
+		`SELECT pi.parcel_id, pi.product_id, COALESCE(p.customer_name, ''), COALESCE(p.brand, ''),
+			COALESCE(p.size, ''), COALESCE(p.quantity, 0), COALESCE(p.photo, '')
+		FROM cc_parcel_item pi
+		JOIN cc_product p ON p.id = pi.product_id
+		WHERE pi.parcel_id=?
+		ORDER BY pi.product_id ASC`,
+		parcelID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ParcelItem, 0)
+	for rows.Next() {
+		item := &ParcelItem{}
+		err := rows.Scan(&item.ParcelID, &item.ProductID, &item.CustomerName, &item.Brand,
+			&item.Size, &item.Quantity, &item.Photo)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
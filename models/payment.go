@@ -0,0 +1,311 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sorting-system/database"
+	"strings"
+	"time"
+)
+
+// 收款类型
+const (
+	PaymentKindDeposit = "deposit"
+	PaymentKindBalance = "balance"
+	PaymentKindRefund  = "refund"
+)
+
+// ValidPaymentKinds 支持的收款类型
+var ValidPaymentKinds = map[string]bool{
+	PaymentKindDeposit: true,
+	PaymentKindBalance: true,
+	PaymentKindRefund:  true,
+}
+
+// Payment 客户收款记录，ProductID 为 0 时记在客户名下，按商品创建时间先后冲抵
+type Payment struct {
+	ID           int     `json:"id"`
+	UserID       int     `json:"user_id"`
+	ProductID    int     `json:"product_id"`
+	CustomerName string  `json:"customer_name"`
+	Kind         string  `json:"kind"`
+	Method       string  `json:"method"`
+	Amount       float64 `json:"amount"`
+	PaidAt       string  `json:"paid_at"`
+	Reference    string  `json:"reference"`
+	Note         string  `json:"note"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+// CustomerBalance 客户应收汇总，Credit 为冲抵全部商品后剩余的预收款
+type CustomerBalance struct {
+	CustomerName string  `json:"customer_name"`
+	TotalAmount  float64 `json:"total_amount"`
+	Received     float64 `json:"received"`
+	Outstanding  float64 `json:"outstanding"`
+	Credit       float64 `json:"credit"`
+}
+
+const paymentColumns = `id, user_id, product_id, customer_name, kind, method, amount, paid_at, reference, note,
+		created_at, updated_at`
+
+// signedPaymentAmount 退款计为负数的 SQL 表达式
+const signedPaymentAmount = "IF(kind='" + PaymentKindRefund + "', -amount, amount)"
+
+func scanPayment(row rowScanner) (*Payment, error) {
+	p := &Payment{}
+	err := row.Scan(&p.ID, &p.UserID, &p.ProductID, &p.CustomerName, &p.Kind, &p.Method, &p.Amount, &p.PaidAt,
+		&p.Reference, &p.Note, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.PaidAt = sortTimeValue(p.PaidAt)
+	return p, nil
+}
+
+// preparePayment 关联商品时以商品的客户名为准，未填收款时间时取当前时间
+func preparePayment(p *Payment) error {
+	if !ValidPaymentKinds[p.Kind] {
+		p.Kind = PaymentKindBalance
+	}
+	if p.ProductID > 0 {
+		product, err := GetProductByID(p.ProductID, p.UserID)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return fmt.Errorf("商品不存在")
+		}
+		p.CustomerName = product.CustomerName
+	}
+	p.CustomerName = strings.TrimSpace(p.CustomerName)
+	if p.CustomerName == "" {
+		return fmt.Errorf("请指定客户或商品")
+	}
+	if p.PaidAt == "" {
+		p.PaidAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+	return nil
+}
+
+// CreatePayment 登记收款并更新客户商品的已付金额
+func CreatePayment(p *Payment) error {
+	if err := preparePayment(p); err != nil {
+		return err
+	}
+
+	result, err := database.DB.Exec(
+		`INSERT INTO cc_payment (user_id, product_id, customer_name, kind, method, amount, paid_at, reference, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.UserID, p.ProductID, p.CustomerName, p.Kind, p.Method, p.Amount, p.PaidAt, p.Reference, p.Note,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = int(id)
+	return SyncCustomerPayments(p.CustomerName)
+}
+
+// UpdatePayment 修改收款，原客户和新客户的已付金额都会重新计算
+func UpdatePayment(p *Payment) error {
+	old, err := GetPaymentByID(p.ID)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return sql.ErrNoRows
+	}
+	if err := preparePayment(p); err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE cc_payment SET product_id=?, customer_name=?, kind=?, method=?, amount=?, paid_at=?, reference=?, note=?
+		WHERE id=?`,
+		p.ProductID, p.CustomerName, p.Kind, p.Method, p.Amount, p.PaidAt, p.Reference, p.Note, p.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if old.CustomerName != p.CustomerName {
+		if err := SyncCustomerPayments(old.CustomerName); err != nil {
+			return err
+		}
+	}
+	return SyncCustomerPayments(p.CustomerName)
+}
+
+// DeletePayment 删除收款
+func DeletePayment(id int) error {
+	old, err := GetPaymentByID(id)
+	if err != nil || old == nil {
+		return err
+	}
+	if _, err := database.DB.Exec(`DELETE FROM cc_payment WHERE id=?`, id); err != nil {
+		return err
+	}
+	return SyncCustomerPayments(old.CustomerName)
+}
+
+// GetPaymentByID 根据ID获取收款
+func GetPaymentByID(id int) (*Payment, error) {
+	p, err := scanPayment(database.DB.QueryRow(`SELECT `+paymentColumns+` FROM cc_payment WHERE id=?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetPaymentList 按客户或商品查询收款记录
+func GetPaymentList(customerName string, productID int) ([]*Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM cc_payment WHERE 1=1`
+	args := []interface{}{}
+	if customerName != "" {
+		query += " AND customer_name=?"
+		args = append(args, customerName)
+	}
+	if productID > 0 {
+		query += " AND product_id=?"
+		args = append(args, productID)
+	}
+	query += " ORDER BY paid_at DESC, id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Payment, 0)
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// GetCustomerBalance 客户的应收、已收与未收
+func GetCustomerBalance(customerName string) (*CustomerBalance, error) {
+	b := &CustomerBalance{CustomerName: customerName}
+	err := database.DB.QueryRow(
+		`SELECT COALESCE(SUM(price_rmb - refunded_amount), 0), COALESCE(SUM(paid_amount), 0)
+		FROM cc_product WHERE customer_name=?`,
+		customerName,
+	).Scan(&b.TotalAmount, &b.Received)
+	if err != nil {
+		return nil, err
+	}
+
+	var payments float64
+	err = database.DB.QueryRow(
+		`SELECT COALESCE(SUM(`+signedPaymentAmount+`), 0) FROM cc_payment WHERE customer_name=?`,
+		customerName,
+	).Scan(&payments)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Outstanding = b.TotalAmount - b.Received
+	b.Credit = math.Max(payments-b.Received, 0)
+	return b, nil
+}
+
+// SyncCustomerPayments 重新计算客户每件商品的已付金额与付款状态
+//
+// 关联商品的收款先计入对应商品，客户名下的收款按商品创建时间先后冲抵剩余金额
+func SyncCustomerPayments(customerName string) error {
+	linked := map[int]float64{}
+	rows, err := database.DB.Query(
+		`SELECT product_id, SUM(`+signedPaymentAmount+`) FROM cc_payment
+		WHERE customer_name=? AND product_id > 0 GROUP BY product_id`,
+		customerName,
+	)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var productID int
+		var amount float64
+		if err := rows.Scan(&productID, &amount); err != nil {
+			rows.Close()
+			return err
+		}
+		linked[productID] = amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var pool float64
+	err = database.DB.QueryRow(
+		`SELECT COALESCE(SUM(`+signedPaymentAmount+`), 0) FROM cc_payment WHERE customer_name=? AND product_id=0`,
+		customerName,
+	).Scan(&pool)
+	if err != nil {
+		return err
+	}
+
+	type productPaid struct {
+		id         int
+		price      float64
+		paidAmount float64
+		paid       bool
+	}
+	products := []*productPaid{}
+	rows, err = database.DB.Query(
+		`SELECT id, price_rmb - refunded_amount, paid_amount, paid FROM cc_product
+		WHERE customer_name=? ORDER BY created_at ASC, id ASC`,
+		customerName,
+	)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		p := &productPaid{}
+		if err := rows.Scan(&p.id, &p.price, &p.paidAmount, &p.paid); err != nil {
+			rows.Close()
+			return err
+		}
+		products = append(products, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		amount := linked[p.id]
+		if pool > 0 && amount < p.price {
+			take := math.Min(pool, p.price-amount)
+			amount += take
+			pool -= take
+		}
+		amount = math.Round(amount*100) / 100
+		paid := p.price > 0 && amount >= p.price-0.005
+		if amount == p.paidAmount && paid == p.paid {
+			continue
+		}
+		if _, err := database.DB.Exec(
+			`UPDATE cc_product SET paid_amount=?, paid=? WHERE id=?`,
+			amount, paid, p.id,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
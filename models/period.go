@@ -0,0 +1,370 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sorting-system/database"
+	"time"
+)
+
+// ErrPeriodClosed 商品或费用所属月份已结账
+var ErrPeriodClosed = errors.New("所属月份已结账，不能修改")
+
+const monthLayout = "2006-01"
+
+// Expense 月度其他费用（房租、包材、人工等）
+type Expense struct {
+	ID        int     `json:"id"`
+	UserID    int     `json:"user_id"`
+	Month     string  `json:"month"`
+	Category  string  `json:"category"`
+	Amount    float64 `json:"amount"`
+	Note      string  `json:"note"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// ClosedPeriod 已结账月份
+type ClosedPeriod struct {
+	Month    string `json:"month"`
+	UserID   int    `json:"user_id"`
+	ClosedAt string `json:"closed_at"`
+}
+
+// MonthlyPL 单月损益，Delta 字段为与上月相比的变化
+type MonthlyPL struct {
+	Month          string  `json:"month"`
+	Revenue        float64 `json:"revenue"`
+	PurchaseCost   float64 `json:"purchase_cost"`
+	Shipping       float64 `json:"shipping"`
+	VatRefund      float64 `json:"vat_refund"`
+	Fees           float64 `json:"fees"` // 刷卡手续费、佣金与其他成本
+	OtherExpenses  float64 `json:"other_expenses"`
+	Returns        float64 `json:"returns"`
+	NetProfit      float64 `json:"net_profit"`
+	Margin         float64 `json:"margin"`
+	RevenueDelta   float64 `json:"revenue_delta"`
+	NetProfitDelta float64 `json:"net_profit_delta"`
+	MarginDelta    float64 `json:"margin_delta"`
+	Closed         bool    `json:"closed"`
+}
+
+// ParseMonth 校验 YYYY-MM 格式的月份
+func ParseMonth(month string) (time.Time, error) {
+	t, err := time.ParseInLocation(monthLayout, month, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("月份格式应为 YYYY-MM: %s", month)
+	}
+	return t, nil
+}
+
+// IsPeriodClosed 判断月份是否已结账
+func IsPeriodClosed(month string) (bool, error) {
+	var n int
+	err := database.DB.QueryRow(`SELECT COUNT(*) FROM cc_period_close WHERE month=?`, month).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ClosePeriod 月份结账，结账后该月商品的金额字段和费用不能再修改
+func ClosePeriod(month string, userID int) error {
+	if _, err := ParseMonth(month); err != nil {
+		return err
+	}
+	_, err := database.DB.Exec(
+		`INSERT IGNORE INTO cc_period_close (month, user_id) VALUES (?, ?)`,
+		month, userID,
+	)
+	return err
+}
+
+// ReopenPeriod 取消月份结账
+func ReopenPeriod(month string) error {
+	_, err := database.DB.Exec(`DELETE FROM cc_period_close WHERE month=?`, month)
+	return err
+}
+
+// GetClosedPeriods 获取已结账月份
+func GetClosedPeriods() ([]*ClosedPeriod, error) {
+	rows, err := database.DB.Query(`SELECT month, user_id, closed_at FROM cc_period_close ORDER BY month DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ClosedPeriod, 0)
+	for rows.Next() {
+		p := &ClosedPeriod{}
+		if err := rows.Scan(&p.Month, &p.UserID, &p.ClosedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// checkMonthOpen 月份已结账时返回 ErrPeriodClosed
+func checkMonthOpen(month string) error {
+	closed, err := IsPeriodClosed(month)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return ErrPeriodClosed
+	}
+	return nil
+}
+
+// checkProductsOpen 任一商品所属月份已结账时返回 ErrPeriodClosed
+func checkProductsOpen(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	var n int
+	err := database.DB.QueryRow(
+		fmt.Sprintf(`SELECT COUNT(*) FROM cc_product p
+		JOIN cc_period_close pc ON pc.month = DATE_FORMAT(p.created_at, '%%Y-%%m')
+		WHERE p.id IN (%s)`, placeholders(len(ids))),
+		args...,
+	).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrPeriodClosed
+	}
+	return nil
+}
+
+// checkProductAmountsOpen 商品所属月份已结账且金额字段有变化时返回 ErrPeriodClosed
+func checkProductAmountsOpen(p *Product) error {
+	old, err := GetProductByID(p.ID, p.UserID)
+	if err != nil || old == nil {
+		return err
+	}
+	if old.CostEur == p.CostEur && old.ExchangeRate == p.ExchangeRate &&
+		old.PriceRMB == p.PriceRMB && old.ShippingFee == p.ShippingFee {
+		return nil
+	}
+	return checkMonthOpen(sortTimeValue(old.CreatedAt)[:len(monthLayout)])
+}
+
+// CreateExpense 登记费用
+func CreateExpense(e *Expense) error {
+	if err := checkMonthOpen(e.Month); err != nil {
+		return err
+	}
+	result, err := database.DB.Exec(
+		`INSERT INTO cc_expense (user_id, month, category, amount, note) VALUES (?, ?, ?, ?, ?)`,
+		e.UserID, e.Month, e.Category, e.Amount, e.Note,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	e.ID = int(id)
+	return nil
+}
+
+// UpdateExpense 修改费用，原月份和新月份都不能已结账
+func UpdateExpense(e *Expense) error {
+	old, err := GetExpenseByID(e.ID)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return sql.ErrNoRows
+	}
+	if err := checkMonthOpen(old.Month); err != nil {
+		return err
+	}
+	if err := checkMonthOpen(e.Month); err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE cc_expense SET month=?, category=?, amount=?, note=? WHERE id=?`,
+		e.Month, e.Category, e.Amount, e.Note, e.ID,
+	)
+	return err
+}
+
+// DeleteExpense 删除费用
+func DeleteExpense(id int) error {
+	old, err := GetExpenseByID(id)
+	if err != nil || old == nil {
+		return err
+	}
+	if err := checkMonthOpen(old.Month); err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`DELETE FROM cc_expense WHERE id=?`, id)
+	return err
+}
+
+// GetExpenseByID 根据ID获取费用
+func GetExpenseByID(id int) (*Expense, error) {
+	e := &Expense{}
+	err := database.DB.QueryRow(
+		`SELECT id, user_id, month, category, amount, note, created_at, updated_at FROM cc_expense WHERE id=?`,
+		id,
+	).Scan(&e.ID, &e.UserID, &e.Month, &e.Category, &e.Amount, &e.Note, &e.CreatedAt, &e.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetExpenseList 获取月份内的费用，month 为空时返回全部
+func GetExpenseList(month string) ([]*Expense, error) {
+	query := `SELECT id, user_id, month, category, amount, note, created_at, updated_at FROM cc_expense`
+	args := []interface{}{}
+	if month != "" {
+		query += " WHERE month=?"
+		args = append(args, month)
+	}
+	query += " ORDER BY month DESC, id ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Expense, 0)
+	for rows.Next() {
+		e := &Expense{}
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Month, &e.Category, &e.Amount, &e.Note, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// GetProfitLoss 按月生成损益表，包含起止月份在内，最多36个月
+func GetProfitLoss(startMonth, endMonth string) ([]*MonthlyPL, error) {
+	start, err := ParseMonth(startMonth)
+	if err != nil {
+		return nil, err
+	}
+	end, err := ParseMonth(endMonth)
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("结束月份不能早于开始月份")
+	}
+	if end.After(start.AddDate(3, 0, -1)) {
+		return nil, fmt.Errorf("最多查询36个月")
+	}
+
+	// 多取开始前一个月用于计算环比
+	from := start.AddDate(0, -1, 0)
+	to := end.AddDate(0, 1, 0)
+
+	byMonth := map[string]*MonthlyPL{}
+	months := []string{}
+	for m := from; m.Before(to); m = m.AddDate(0, 1, 0) {
+		key := m.Format(monthLayout)
+		byMonth[key] = &MonthlyPL{Month: key}
+		months = append(months, key)
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT DATE_FORMAT(created_at, '%Y-%m'),
+			COALESCE(SUM(price_rmb), 0), COALESCE(SUM(cost_rmb), 0), COALESCE(SUM(shipping_fee), 0),
+			COALESCE(SUM(return_adjustment), 0), COALESCE(SUM(vat_refund), 0),
+			COALESCE(SUM(card_fee + commission + other_cost), 0)
+		FROM cc_product
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY DATE_FORMAT(created_at, '%Y-%m')`,
+		from.Format("2006-01-02 15:04:05"), to.Format("2006-01-02 15:04:05"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var month string
+		var revenue, cost, shipping, returns, vatRefund, fees float64
+		if err := rows.Scan(&month, &revenue, &cost, &shipping, &returns, &vatRefund, &fees); err != nil {
+			return nil, err
+		}
+		if pl, ok := byMonth[month]; ok {
+			pl.Revenue, pl.PurchaseCost, pl.Shipping, pl.Returns = revenue, cost, shipping, returns
+			pl.VatRefund, pl.Fees = vatRefund, fees
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	expenseRows, err := database.DB.Query(
+		`SELECT month, COALESCE(SUM(amount), 0) FROM cc_expense WHERE month >= ? AND month <= ? GROUP BY month`,
+		months[0], months[len(months)-1],
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer expenseRows.Close()
+
+	for expenseRows.Next() {
+		var month string
+		var amount float64
+		if err := expenseRows.Scan(&month, &amount); err != nil {
+			return nil, err
+		}
+		if pl, ok := byMonth[month]; ok {
+			pl.OtherExpenses = amount
+		}
+	}
+	if err := expenseRows.Err(); err != nil {
+		return nil, err
+	}
+
+	closed, err := GetClosedPeriods()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range closed {
+		if pl, ok := byMonth[p.Month]; ok {
+			pl.Closed = true
+		}
+	}
+
+	list := make([]*MonthlyPL, 0, len(months)-1)
+	var prev *MonthlyPL
+	for _, month := range months {
+		pl := byMonth[month]
+		pl.NetProfit = pl.Revenue - pl.PurchaseCost - pl.Shipping - pl.Fees + pl.VatRefund - pl.OtherExpenses + pl.Returns
+		if pl.Revenue != 0 {
+			pl.Margin = pl.NetProfit / pl.Revenue * 100
+		}
+		if prev != nil {
+			pl.RevenueDelta = pl.Revenue - prev.Revenue
+			pl.NetProfitDelta = pl.NetProfit - prev.NetProfit
+			pl.MarginDelta = pl.Margin - prev.Margin
+			list = append(list, pl)
+		}
+		prev = pl
+	}
+	return list, nil
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sorting-system/database"
+)
+
+// PickList 拣货单，按到货记录或到货日期范围生成，商品全部扫码后自动完成
+type PickList struct {
+	ID          int         `json:"id"`
+	UserID      int         `json:"user_id"`
+	Name        string      `json:"name"`
+	ArrivalID   int         `json:"arrival_id"`
+	StartTime   string      `json:"start_time"`
+	EndTime     string      `json:"end_time"`
+	Total       int         `json:"total"`
+	Scanned     int         `json:"scanned"`
+	CompletedAt *string     `json:"completed_at"`
+	CreatedAt   string      `json:"created_at"`
+	Areas       []*PickArea `json:"areas,omitempty"`
+}
+
+// PickArea 拣货单中一个区域的商品
+type PickArea struct {
+	AreaID   int         `json:"area_id"`
+	AreaName string      `json:"area_name"`
+	Items    []*PickItem `json:"items"`
+}
+
+// PickItem 拣货单商品
+type PickItem struct {
+	ProductID    int     `json:"product_id"`
+	Photo        string  `json:"photo"`
+	CustomerName string  `json:"customer_name"`
+	Brand        string  `json:"brand"`
+	Size         string  `json:"size"`
+	Quantity     int     `json:"quantity"`
+	ScannedAt    *string `json:"scanned_at"`
+}
+
+const pickListColumns = `l.id, l.user_id, l.name, l.arrival_id, l.start_time, l.end_time, l.completed_at, l.created_at,
+		(SELECT COUNT(*) FROM cc_pick_list_item i WHERE i.pick_list_id = l.id),
+		(SELECT COUNT(*) FROM cc_pick_list_item i WHERE i.pick_list_id = l.id AND i.scanned_at IS NOT NULL)`
+
+func scanPickList(row rowScanner) (*PickList, error) {
+	l := &PickList{}
+	err := row.Scan(&l.ID, &l.UserID, &l.Name, &l.ArrivalID, &l.StartTime, &l.EndTime, &l.CompletedAt, &l.CreatedAt,
+		&l.Total, &l.Scanned)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// CreatePickList 生成拣货单：指定到货记录时取其关联商品，否则取到货日期在范围内的到货记录关联的商品
+//
+// 只能使用自己的到货记录
+func CreatePickList(l *PickList) error {
+	query := `SELECT DISTINCT ai.product_id FROM cc_arrival_item ai JOIN cc_arrival ar ON ar.id = ai.arrival_id
+		WHERE ar.user_id=?`
+	args := []interface{}{l.UserID}
+	if l.ArrivalID > 0 {
+		arrival, err := GetArrivalByID(l.ArrivalID, l.UserID)
+		if err != nil {
+			return err
+		}
+		if arrival == nil {
+			return fmt.Errorf("到货记录不存在")
+		}
+		query += " AND ai.arrival_id=?"
+		args = append(args, l.ArrivalID)
+	} else {
+		if l.StartTime == "" || l.EndTime == "" {
+			return fmt.Errorf("请指定到货记录或到货日期范围")
+		}
+		query += " AND ar.arrival_date >= ? AND ar.arrival_date <= ?"
+		args = append(args, l.StartTime, l.EndTime)
+	}
+
+	ids, err := queryIDs(query, args...)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("没有已关联商品的到货记录")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO cc_pick_list (user_id, name, arrival_id, start_time, end_time) VALUES (?, ?, ?, ?, ?)`,
+		l.UserID, l.Name, l.ArrivalID, l.StartTime, l.EndTime,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	l.ID = int(id)
+
+	for _, productID := range ids {
+		if _, err := tx.Exec(`INSERT INTO cc_pick_list_item (pick_list_id, product_id) VALUES (?, ?)`, l.ID, productID); err != nil {
+			return err
+		}
+	}
+	l.Total = len(ids)
+	return tx.Commit()
+}
+
+// DeletePickList 删除拣货单，非管理员只能删除自己的拣货单；不存在时返回 sql.ErrNoRows
+func DeletePickList(id, userID int) error {
+	query, args := `DELETE FROM cc_pick_list WHERE id=?`, []interface{}{id}
+	if userID != 1 {
+		query += " AND user_id=?"
+		args = append(args, userID)
+	}
+	result, err := database.DB.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = database.DB.Exec(`DELETE FROM cc_pick_list_item WHERE pick_list_id=?`, id)
+	return err
+}
+
+// GetPickListList 获取最近的拣货单，非管理员只能看到自己的拣货单
+func GetPickListList(userID int) ([]*PickList, error) {
+	query, args := `SELECT `+pickListColumns+` FROM cc_pick_list l`, []interface{}{}
+	if userID != 1 {
+		query += " WHERE l.user_id=?"
+		args = append(args, userID)
+	}
+	rows, err := database.DB.Query(query+" ORDER BY l.id DESC LIMIT 100", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*PickList, 0)
+	for rows.Next() {
+		l, err := scanPickList(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+// GetPickList 获取拣货单及按区域分组的商品，非管理员只能查看自己的拣货单
+func GetPickList(id, userID int) (*PickList, error) {
+	l, err := scanPickList(database.DB.QueryRow(`SELECT `+pickListColumns+` FROM cc_pick_list l WHERE l.id=?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID != 1 && l.UserID != userID {
+		return nil, nil
+	}
+
+	rows, err := database.DB.Query(
+		`SELECT i.product_id, COALESCE(p.area_id, 0), COALESCE(a.name, ''), COALESCE(p.photo, ''),
+			COALESCE(p.customer_name, ''), COALESCE(p.brand, ''), COALESCE(p.size, ''), COALESCE(p.quantity, 0), i.scanned_at
+		FROM cc_pick_list_item i
+		JOIN cc_product p ON p.id = i.product_id
+		LEFT JOIN cc_product_area a ON a.id = p.area_id
+		WHERE i.pick_list_id=?
+		ORDER BY COALESCE(p.area_id, 0) ASC, p.customer_name ASC, i.product_id ASC`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l.Areas = []*PickArea{}
+	var current *PickArea
+	for rows.Next() {
+		item := &PickItem{}
+		var areaID int
+		var areaName string
+		err := rows.Scan(&item.ProductID, &areaID, &areaName, &item.Photo,
+			&item.CustomerName, &item.Brand, &item.Size, &item.Quantity, &item.ScannedAt)
+		if err != nil {
+			return nil, err
+		}
+		if item.ScannedAt != nil {
+			s := sortTimeValue(*item.ScannedAt)
+			item.ScannedAt = &s
+		}
+		if current == nil || current.AreaID != areaID {
+			if areaName == "" {
+				areaName = "未分区"
+			}
+			current = &PickArea{AreaID: areaID, AreaName: areaName, Items: []*PickItem{}}
+			l.Areas = append(l.Areas, current)
+		}
+		current.Items = append(current.Items, item)
+	}
+	return l, rows.Err()
+}
+
+// markPickListScanned 将商品在未完成拣货单中标记为已扫码，全部扫完的拣货单标记为完成
+func markPickListScanned(productID int) error {
+	_, err := database.DB.Exec(
+		`UPDATE cc_pick_list_item i JOIN cc_pick_list l ON l.id = i.pick_list_id
+		SET i.scanned_at=NOW()
+		WHERE i.product_id=? AND i.scanned_at IS NULL AND l.completed_at IS NULL`,
+		productID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE cc_pick_list l SET l.completed_at=NOW()
+		WHERE l.completed_at IS NULL
+		AND EXISTS (SELECT 1 FROM cc_pick_list_item i WHERE i.pick_list_id = l.id AND i.product_id=?)
+		AND NOT EXISTS (SELECT 1 FROM cc_pick_list_item i WHERE i.pick_list_id = l.id AND i.scanned_at IS NULL)`,
+		productID,
+	)
+	return err
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"database/sql"
+	"math"
+	"sorting-system/config"
+	"sorting-system/database"
+)
+
+// sqlRunner 兼容 *sql.DB 与 *sql.Tx
+type sqlRunner interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Pricing 当前的成本与利润计算参数，未配置时各项为0
+func Pricing() config.PricingConfig {
+	if config.GlobalConfig == nil {
+		return config.PricingConfig{}
+	}
+	cfg := config.GlobalConfig.Pricing
+	if cfg.ExtraCosts == nil {
+		cfg.ExtraCosts = []config.CostItem{}
+	}
+	return cfg
+}
+
+// round2 保留2位小数
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// calculateProduct 按计算参数重算商品的派生金额，所有写入金额的路径都应调用
+//
+// 总成本 = 采购成本RMB + 运费 + 刷卡手续费 + 佣金 + 其他成本 - 退税
+// 利润 = 售价 - 总成本 + 退货调整
+func calculateProduct(p *Product) {
+	cfg := Pricing()
+
+	p.CostRMB = p.CostEur * p.ExchangeRate
+	p.VatRefund = round2(p.CostRMB * cfg.VatRefundRate / 100)
+	p.CardFee = round2(p.CostRMB * cfg.CardFeeRate / 100)
+	p.Commission = round2(p.PriceRMB*cfg.CommissionRate/100 + cfg.CommissionFixed)
+
+	p.OtherCost = 0
+	for _, item := range cfg.ExtraCosts {
+		amount := item.Amount
+		switch item.Base {
+		case "cost":
+			amount += p.CostRMB * item.Rate / 100
+		case "price":
+			amount += p.PriceRMB * item.Rate / 100
+		}
+		p.OtherCost += round2(amount)
+	}
+
+	p.TotalCost = p.CostRMB + p.ShippingFee + p.CardFee + p.Commission + p.OtherCost - p.VatRefund
+	p.Profit = p.PriceRMB - p.TotalCost + p.ReturnAdjustment
+}
+
+// recalculateProduct 读取商品，执行 update 修改后按计算参数重算并保存金额字段
+func recalculateProduct(db sqlRunner, id int, update func(p *Product)) error {
+	p, err := scanProduct(db.QueryRow(`SELECT `+productColumns+` FROM cc_product WHERE id=?`, id))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if update != nil {
+		update(p)
+	}
+	calculateProduct(p)
+
+	_, err = db.Exec(
+		`UPDATE cc_product SET shipping_fee=?, shipping_confirmed=?, cost_rmb=?,
+		vat_refund=?, card_fee=?, commission=?, other_cost=?, total_cost=?, profit=?
+		WHERE id=?`,
+		p.ShippingFee, p.ShippingConfirmed, p.CostRMB,
+		p.VatRefund, p.CardFee, p.Commission, p.OtherCost, p.TotalCost, p.Profit, id,
+	)
+	return err
+}
+
+// RecalculateProducts 计算参数调整后重算商品金额，ids 为空时重算所有未结账月份的商品
+//
+// 指定的商品中有已结账月份的返回 ErrPeriodClosed
+func RecalculateProducts(ids []int) (int, error) {
+	if len(ids) == 0 {
+		var err error
+		ids, err = queryIDs(`SELECT p.id FROM cc_product p
+			WHERE NOT EXISTS (SELECT 1 FROM cc_period_close pc WHERE pc.month = DATE_FORMAT(p.created_at, '%Y-%m'))
+			ORDER BY p.id ASC`)
+		if err != nil {
+			return 0, err
+		}
+	} else if err := checkProductsOpen(ids); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := recalculateProduct(database.DB, id, nil); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
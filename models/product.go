@@ -5,49 +5,185 @@ import (
 	"fmt"
 	"regexp"
 	"sorting-system/database"
+	"sorting-system/filter"
+	"sorting-system/search"
+	"sorting-system/validate"
 	"strings"
+	"time"
 )
 
 type Product struct {
-	SID             int     `json:"sid"`
-	ID              int     `json:"id"`
-	UserID          int     `json:"user_id"`
-	AreaID          *int    `json:"area_id"`
-	Photo           string  `json:"photo"`
-	CustomerName    string  `json:"customer_name"`
-	Brand           string  `json:"brand"`
-	Size            string  `json:"size"`
-	Quantity        int     `json:"quantity"`
-	Address         string  `json:"address"`
-	Mark            string  `json:"mark"`
-	StatusNotePhoto string  `json:"status_note_photo"`
-	CostEur         float64 `json:"cost_eur"`
-	ExchangeRate    float64 `json:"exchange_rate"`
-	CostRMB         float64 `json:"cost_rmb"`
-	PriceRMB        float64 `json:"price_rmb"`
-	ShippingFee     float64 `json:"shipping_fee"`
-	TotalCost       float64 `json:"total_cost"`
-	Profit          float64 `json:"profit"`
-	CreatedAt       string  `json:"created_at"`
-	UpdatedAt       string  `json:"updated_at"`
+	SID              int     `json:"sid"`
+	ID               int     `json:"id"`
+	UserID           int     `json:"user_id"`
+	AreaID           *int    `json:"area_id"`
+	Photo            string  `json:"photo"`
+	CustomerName     string  `json:"customer_name"`
+	Brand            string  `json:"brand"`
+	Size             string  `json:"size"`
+	Quantity         int     `json:"quantity"`
+	Address          string  `json:"address"`
+	Mark             string  `json:"mark"`
+	StatusNotePhoto  string  `json:"status_note_photo"`
+	PhotoHash        string  `json:"photo_hash"`
+	Paid             bool    `json:"paid"`
+	PaidAmount       float64 `json:"paid_amount"`
+	RefundedAmount   float64 `json:"refunded_amount"`
+	ReturnAdjustment float64 `json:"return_adjustment"`
+	CostEur          float64 `json:"cost_eur"`
+	ExchangeRate     float64 `json:"exchange_rate"`
+	CostRMB          float64 `json:"cost_rmb"`
+	PriceRMB         float64 `json:"price_rmb"`
+	ShippingFee      float64 `json:"shipping_fee"`
+	// VatRefund、CardFee、Commission、OtherCost 由 calculateProduct 按计算参数生成
+	VatRefund  float64 `json:"vat_refund"`
+	CardFee    float64 `json:"card_fee"`
+	Commission float64 `json:"commission"`
+	OtherCost  float64 `json:"other_cost"`
+	// ShippingConfirmed 为 false 时 shipping_fee 按重量、尺寸和线路运价自动估算
+	ShippingConfirmed bool    `json:"shipping_confirmed"`
+	Weight            float64 `json:"weight"`
+	Length            float64 `json:"length"`
+	Width             float64 `json:"width"`
+	Height            float64 `json:"height"`
+	Route             string  `json:"route"`
+	VolumetricWeight  float64 `json:"volumetric_weight"`
+	ChargeableWeight  float64 `json:"chargeable_weight"`
+	// SortedAt 扫码分拣时间，为空表示未分拣
+	SortedAt *string `json:"sorted_at"`
+	// OutboundStatus 所在发货包裹的状态，为空表示未装入包裹
+	OutboundStatus string  `json:"outbound_status"`
+	TotalCost      float64 `json:"total_cost"`
+	Profit         float64 `json:"profit"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+	Tags           []*Tag  `json:"tags,omitempty"`
 }
 
+// ProductListResponse 商品列表；Total 为 -1 表示未统计，游标翻页时 Summary 为空
 type ProductListResponse struct {
-	Total    int64      `json:"total"`
-	Page     int        `json:"page"`
-	PageSize int        `json:"page_size"`
-	List     []*Product `json:"list"`
-	Summary  *Summary   `json:"summary"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	List       []*Product `json:"list"`
+	Summary    *Summary   `json:"summary"`
+	HasMore    bool       `json:"has_more"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 type Summary struct {
-	TotalCostEur     float64 `json:"total_cost_eur"`
-	TotalCostRMB     float64 `json:"total_cost_rmb"`
-	TotalPriceRMB    float64 `json:"total_price_rmb"`
-	TotalShippingFee float64 `json:"total_shipping_fee"`
-	TotalCost        float64 `json:"total_cost"`
-	TotalProfit      float64 `json:"total_profit"`
-	TotalQuantity    int     `json:"total_quantity"`
+	TotalCostEur     float64        `json:"total_cost_eur"`
+	TotalCostRMB     float64        `json:"total_cost_rmb"`
+	TotalPriceRMB    float64        `json:"total_price_rmb"`
+	TotalShippingFee float64        `json:"total_shipping_fee"`
+	TotalVatRefund   float64        `json:"total_vat_refund"`
+	TotalCardFee     float64        `json:"total_card_fee"`
+	TotalCommission  float64        `json:"total_commission"`
+	TotalOtherCost   float64        `json:"total_other_cost"`
+	TotalCost        float64        `json:"total_cost"`
+	TotalProfit      float64        `json:"total_profit"`
+	TotalQuantity    int            `json:"total_quantity"`
+	TotalReceived    float64        `json:"total_received"`
+	TotalOutstanding float64        `json:"total_outstanding"`
+	Returns          *ReturnSummary `json:"returns"`
+	Tags             []*TagSummary  `json:"tags"`
+}
+
+// productColumns cc_product 查询的标准列顺序，需与 scanProduct 保持一致
+const productColumns = `id, user_id, area_id, photo, customer_name, size, quantity, address, status_note_photo,
+		cost_eur, exchange_rate, cost_rmb, price_rmb, shipping_fee, total_cost, profit,
+		created_at, updated_at, mark, brand, photo_hash, paid, paid_amount,
+		refunded_amount, return_adjustment, weight, length, width, height, route, shipping_confirmed,
+		sorted_at, vat_refund, card_fee, commission, other_cost, outbound_status`
+
+// ProductSearchSchema 商品搜索框支持的字段，全文列需与 ft_product_search 索引一致
+var ProductSearchSchema = &search.Schema{
+	Fields: map[string]search.Field{
+		"id":                {Column: "id", Kind: search.KindNumber},
+		"customer":          {Column: "customer_name", Kind: search.KindText},
+		"customer_name":     {Column: "customer_name", Kind: search.KindText},
+		"客户":                {Column: "customer_name", Kind: search.KindText},
+		"brand":             {Column: "brand", Kind: search.KindText},
+		"品牌":                {Column: "brand", Kind: search.KindText},
+		"size":              {Column: "size", Kind: search.KindText},
+		"尺码":                {Column: "size", Kind: search.KindText},
+		"address":           {Column: "address", Kind: search.KindText},
+		"地址":                {Column: "address", Kind: search.KindText},
+		"mark":              {Column: "mark", Kind: search.KindText},
+		"备注":                {Column: "mark", Kind: search.KindText},
+		"photo":             {Column: "photo", Kind: search.KindText},
+		"status_note_photo": {Column: "status_note_photo", Kind: search.KindText},
+		"area_id":           {Column: "area_id", Kind: search.KindNumber},
+		"quantity":          {Column: "quantity", Kind: search.KindNumber},
+		"cost_eur":          {Column: "cost_eur", Kind: search.KindNumber},
+		"exchange_rate":     {Column: "exchange_rate", Kind: search.KindNumber},
+		"cost_rmb":          {Column: "cost_rmb", Kind: search.KindNumber},
+		"price_rmb":         {Column: "price_rmb", Kind: search.KindNumber},
+		"price":             {Column: "price_rmb", Kind: search.KindNumber},
+		"shipping_fee":      {Column: "shipping_fee", Kind: search.KindNumber},
+		"total_cost":        {Column: "total_cost", Kind: search.KindNumber},
+		"profit":            {Column: "profit", Kind: search.KindNumber},
+		"利润":                {Column: "profit", Kind: search.KindNumber},
+		"created_at":        {Column: "created_at", Kind: search.KindDate},
+		"created":           {Column: "created_at", Kind: search.KindDate},
+		"updated_at":        {Column: "updated_at", Kind: search.KindDate},
+		"paid":              {Column: "paid", Kind: search.KindNumber},
+		"weight":            {Column: "weight", Kind: search.KindNumber},
+		"重量":                {Column: "weight", Kind: search.KindNumber},
+		"route":             {Column: "route", Kind: search.KindText},
+		"线路":                {Column: "route", Kind: search.KindText},
+		"sorted_at":         {Column: "sorted_at", Kind: search.KindDate},
+		"outbound_status":   {Column: "outbound_status", Kind: search.KindText},
+		"发货":                {Column: "outbound_status", Kind: search.KindText},
+	},
+	FullText:  []string{"customer_name", "brand", "size", "address", "mark"},
+	IDColumn:  "id",
+	TagClause: "EXISTS (SELECT 1 FROM cc_product_tag pt WHERE pt.product_id = cc_product.id AND pt.tag_id IN (%s))",
+}
+
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct 按 productColumns 的顺序扫描一行商品
+func scanProduct(row rowScanner) (*Product, error) {
+	p := &Product{}
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.AreaID, &p.Photo, &p.CustomerName, &p.Size, &p.Quantity, &p.Address, &p.StatusNotePhoto,
+		&p.CostEur, &p.ExchangeRate, &p.CostRMB, &p.PriceRMB, &p.ShippingFee, &p.TotalCost, &p.Profit,
+		&p.CreatedAt, &p.UpdatedAt, &p.Mark, &p.Brand, &p.PhotoHash, &p.Paid, &p.PaidAmount,
+		&p.RefundedAmount, &p.ReturnAdjustment, &p.Weight, &p.Length, &p.Width, &p.Height, &p.Route, &p.ShippingConfirmed,
+		&p.SortedAt, &p.VatRefund, &p.CardFee, &p.Commission, &p.OtherCost, &p.OutboundStatus,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.VolumetricWeight = VolumetricWeight(p.Length, p.Width, p.Height)
+	p.ChargeableWeight = ChargeableWeight(p.Weight, p.Length, p.Width, p.Height)
+	if p.SortedAt != nil {
+		sortedAt := sortTimeValue(*p.SortedAt)
+		p.SortedAt = &sortedAt
+	}
+	return p, nil
+}
+
+// maskProductCost 非管理员隐藏成本与利润字段
+func maskProductCost(p *Product, userID int) {
+	if userID == 1 {
+		return
+	}
+	p.CostEur = 0.0
+	p.ExchangeRate = 0.0
+	p.CostRMB = 0.0
+	p.TotalCost = 0.0
+	p.Profit = 0.0
+	p.ShippingFee = 0.0
+	p.ReturnAdjustment = 0.0
+	p.VatRefund = 0.0
+	p.CardFee = 0.0
+	p.Commission = 0.0
+	p.OtherCost = 0.0
 }
 
 // parseQuantityFromSize 从尺码字符串中解析件数
@@ -90,19 +226,47 @@ func parseQuantityFromSize(size string) int {
 }
 
 func CreateProduct(p *Product) error {
+	brand, err := NormalizeBrand(p.Brand)
+	if err != nil {
+		return err
+	}
+	p.Brand = brand
+	if err := ValidateProduct(p, nil, p.UserID); err != nil {
+		return err
+	}
+	if err := checkMonthOpen(time.Now().Format(monthLayout)); err != nil {
+		return err
+	}
+
 	// 计算字段
 	p.Quantity = parseQuantityFromSize(p.Size)
-	p.CostRMB = p.CostEur * p.ExchangeRate
-	p.TotalCost = p.CostRMB + p.ShippingFee
-	p.Profit = p.PriceRMB - p.TotalCost
+	applyShippingEstimate(p)
+	p.ReturnAdjustment = 0
+	calculateProduct(p)
+	p.PhotoHash = photoHash(p.Photo)
+
+	if err := insertProduct(database.DB, p); err != nil {
+		return err
+	}
+	if err := syncPrimaryAttachments(p, p.UserID); err != nil {
+		return err
+	}
+	return SyncCustomerPayments(p.CustomerName)
+}
 
-	result, err := database.DB.Exec(
+// insertProduct 写入新商品并设置 p.ID，金额字段需已计算
+func insertProduct(db sqlRunner, p *Product) error {
+	result, err := db.Exec(
 		`INSERT INTO cc_product
 		(user_id, area_id, photo, customer_name, size, quantity, address, status_note_photo,
-		cost_eur, exchange_rate, cost_rmb, price_rmb, shipping_fee, total_cost, profit,mark,brand)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		cost_eur, exchange_rate, cost_rmb, price_rmb, shipping_fee, total_cost, profit,mark,brand,photo_hash,
+		weight, length, width, height, route, shipping_confirmed,
+		vat_refund, card_fee, commission, other_cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		p.UserID, p.AreaID, p.Photo, p.CustomerName, p.Size, p.Quantity, p.Address, p.StatusNotePhoto,
-		p.CostEur, p.ExchangeRate, p.CostRMB, p.PriceRMB, p.ShippingFee, p.TotalCost, p.Profit, p.Mark, p.Brand,
+		p.CostEur, p.ExchangeRate, p.CostRMB, p.PriceRMB, p.ShippingFee, p.TotalCost, p.Profit, p.Mark, p.Brand, p.PhotoHash,
+		p.Weight, p.Length, p.Width, p.Height, p.Route, p.ShippingConfirmed,
+		p.VatRefund, p.CardFee, p.Commission, p.OtherCost,
 	)
 	if err != nil {
 		return err
@@ -116,26 +280,97 @@ func CreateProduct(p *Product) error {
 	return nil
 }
 
-func UpdateProduct(p *Product) error {
-	// 计算字段
-	p.Quantity = parseQuantityFromSize(p.Size)
-	p.CostRMB = p.CostEur * p.ExchangeRate
-	p.TotalCost = p.CostRMB + p.ShippingFee
-	p.Profit = p.PriceRMB - p.TotalCost
-
-	_, err := database.DB.Exec(
+// updateProductRow 保存商品的可写字段与金额字段，金额字段需已计算
+func updateProductRow(db sqlRunner, p *Product) error {
+	_, err := db.Exec(
 		`UPDATE cc_product SET
 		area_id=?, photo=?, customer_name=?, size=?, quantity=?, address=?, status_note_photo=?,
 		cost_eur=?, exchange_rate=?, cost_rmb=?, price_rmb=?, shipping_fee=?,
-		total_cost=?, profit=?,mark=?,brand=? 
+		total_cost=?, profit=?,mark=?,brand=?,photo_hash=?,
+		weight=?, length=?, width=?, height=?, route=?, shipping_confirmed=?,
+		vat_refund=?, card_fee=?, commission=?, other_cost=?
 		WHERE id=?`,
 		p.AreaID, p.Photo, p.CustomerName, p.Size, p.Quantity, p.Address, p.StatusNotePhoto,
 		p.CostEur, p.ExchangeRate, p.CostRMB, p.PriceRMB, p.ShippingFee,
-		p.TotalCost, p.Profit, p.Mark, p.Brand, p.ID,
+		p.TotalCost, p.Profit, p.Mark, p.Brand, p.PhotoHash,
+		p.Weight, p.Length, p.Width, p.Height, p.Route, p.ShippingConfirmed,
+		p.VatRefund, p.CardFee, p.Commission, p.OtherCost, p.ID,
 	)
 	return err
 }
 
+// UpdateProduct 保存商品修改，userID 为操作用户而不是商品的录入人
+func UpdateProduct(p *Product, userID int) error {
+	old, err := GetProductByID(p.ID, p.UserID)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		// 运费确认状态只能通过确认接口取消；手动改运费视为确认
+		p.ShippingConfirmed = old.ShippingConfirmed || p.ShippingFee != old.ShippingFee
+	}
+
+	if p.Brand, err = NormalizeBrand(p.Brand); err != nil {
+		return err
+	}
+	if err := ValidateProduct(p, old, userID); err != nil {
+		return err
+	}
+
+	closed := false
+	if old != nil {
+		if closed, err = IsPeriodClosed(sortTimeValue(old.CreatedAt)[:len(monthLayout)]); err != nil {
+			return err
+		}
+	}
+
+	// 计算字段；已结账月份不重新估算运费，修改重量、尺寸不会改动运费
+	p.Quantity = parseQuantityFromSize(p.Size)
+	if closed {
+		p.VolumetricWeight = VolumetricWeight(p.Length, p.Width, p.Height)
+		p.ChargeableWeight = ChargeableWeight(p.Weight, p.Length, p.Width, p.Height)
+	} else {
+		applyShippingEstimate(p)
+	}
+
+	// 已结账月份的商品只能修改非金额字段
+	if err := checkProductAmountsOpen(p); err != nil {
+		return err
+	}
+
+	if old != nil {
+		// 退货金额只由退货记录维护
+		p.RefundedAmount = old.RefundedAmount
+		p.ReturnAdjustment = old.ReturnAdjustment
+	}
+	calculateProduct(p)
+	if closed {
+		// 已结账月份的金额不随计算参数调整而变化
+		p.CostRMB, p.TotalCost, p.Profit = old.CostRMB, old.TotalCost, old.Profit
+		p.VatRefund, p.CardFee, p.Commission, p.OtherCost = old.VatRefund, old.CardFee, old.Commission, old.OtherCost
+	}
+	p.PhotoHash = photoHash(p.Photo)
+
+	if err := updateProductRow(database.DB, p); err != nil {
+		return err
+	}
+	// 直接修改 photo、status_note_photo 时同步到附件列表
+	if err := syncPrimaryAttachments(p, userID); err != nil {
+		return err
+	}
+
+	// 客户名变化时收款随商品转到新客户
+	if old != nil && old.CustomerName != p.CustomerName {
+		if _, err := database.DB.Exec(`UPDATE cc_payment SET customer_name=? WHERE product_id=?`, p.CustomerName, p.ID); err != nil {
+			return err
+		}
+		if err := SyncCustomerPayments(old.CustomerName); err != nil {
+			return err
+		}
+	}
+	return SyncCustomerPayments(p.CustomerName)
+}
+
 func UpdateProductField(id, userID int, field string, value interface{}) (*Product, error) {
 	// 获取当前产品
 	product, err := GetProductByID(id, userID)
@@ -146,47 +381,59 @@ func UpdateProductField(id, userID int, field string, value interface{}) (*Produ
 		return nil, fmt.Errorf("产品不存在")
 	}
 
+	// 转换并校验字段值，不支持的字段和类型不符的值返回校验错误
+	v, fe := ProductSchema.Coerce(field, value)
+	if fe != nil {
+		return nil, validate.Errors{fe}
+	}
+	str, _ := v.(string)
+	num, _ := v.(float64)
+
 	// 更新指定字段
 	switch field {
 	case "area_id":
-		if value == nil {
-			product.AreaID = nil
-		} else {
-			areaID := int(value.(float64))
+		if areaID, ok := v.(int); ok {
 			product.AreaID = &areaID
+		} else {
+			product.AreaID = nil
 		}
 	case "photo":
-		product.Photo = value.(string)
+		product.Photo = str
 	case "customer_name":
-		product.CustomerName = value.(string)
+		product.CustomerName = str
 	case "brand":
-		product.Brand = value.(string)
+		product.Brand = str
 	case "size":
-		product.Size = value.(string)
+		product.Size = str
 		product.Quantity = parseQuantityFromSize(product.Size)
 	case "address":
-		product.Address = value.(string)
+		product.Address = str
 	case "mark":
-		product.Mark = value.(string)
+		product.Mark = str
 	case "status_note_photo":
-		product.StatusNotePhoto = value.(string)
+		product.StatusNotePhoto = str
 	case "cost_eur":
-		product.CostEur = value.(float64)
+		product.CostEur = num
 	case "exchange_rate":
-		product.ExchangeRate = value.(float64)
+		product.ExchangeRate = num
 	case "price_rmb":
-		product.PriceRMB = value.(float64)
+		product.PriceRMB = num
 	case "shipping_fee":
-		product.ShippingFee = value.(float64)
+		product.ShippingFee = num
+	case "weight":
+		product.Weight = num
+	case "length":
+		product.Length = num
+	case "width":
+		product.Width = num
+	case "height":
+		product.Height = num
+	case "route":
+		product.Route = str
 	}
 
-	// 重新计算
-	product.CostRMB = product.CostEur * product.ExchangeRate
-	product.TotalCost = product.CostRMB + product.ShippingFee
-	product.Profit = product.PriceRMB - product.TotalCost
-
-	// 保存
-	err = UpdateProduct(product)
+	// 保存（UpdateProduct 会重新计算金额）
+	err = UpdateProduct(product, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -198,6 +445,14 @@ func DeleteProducts(ids []int, userID int) error {
 	if len(ids) == 0 {
 		return nil
 	}
+	if err := checkProductsOpen(ids); err != nil {
+		return err
+	}
+	// 商品所在的已分摊运单在删除后按剩余商品重新分摊
+	shipmentIDs, err := allocatedShipmentsOf(ids)
+	if err != nil {
+		return err
+	}
 
 	placeholders := make([]string, len(ids))
 	args := make([]interface{}, len(ids))
@@ -207,25 +462,99 @@ func DeleteProducts(ids []int, userID int) error {
 		args[i] = id
 	}
 
-	query := fmt.Sprintf("DELETE FROM cc_product WHERE id IN (%s)",
+	// 删除前记下商品和关联收款的客户，删除后重新冲抵其已付金额
+	var customers []string
+	rows, err := database.DB.Query(fmt.Sprintf(
+		`SELECT customer_name FROM cc_product WHERE id IN (%[1]s)
+		UNION SELECT customer_name FROM cc_payment WHERE product_id IN (%[1]s)`,
+		strings.Join(placeholders, ",")), append(args, args...)...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		if name.String != "" {
+			customers = append(customers, name.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 商品及其到货明细、标签、附件、运单明细、退货记录、拣货单明细和包裹明细
+	tables := []struct{ table, column string }{
+		{"cc_product", "id"},
+		{"cc_arrival_item", "product_id"},
+		{"cc_product_tag", "product_id"},
+		{"cc_product_attachment", "product_id"},
+		{"cc_shipment_item", "product_id"},
+		{"cc_product_return", "product_id"},
+		{"cc_pick_list_item", "product_id"},
+		{"cc_parcel_item", "product_id"},
+	}
+	for _, t := range tables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", t.table, t.column, strings.Join(placeholders, ","))
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteTargetComments(tx, CommentTargetProduct, ids); err != nil {
+		return err
+	}
+
+	// 关联到被删商品的收款改为客户名下的收款，保留收款记录
+	query := fmt.Sprintf("UPDATE cc_payment SET product_id=0 WHERE product_id IN (%s)",
 		strings.Join(placeholders, ","))
-	_, err := database.DB.Exec(query, args...)
-	return err
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, name := range customers {
+		if err := SyncCustomerPayments(name); err != nil {
+			return err
+		}
+	}
+	return reallocateShipments(shipmentIDs)
+}
+
+// DeleteProductsByFilter 删除符合筛选条件的商品，返回删除数量
+//
+// 以编译后的条件判断是否为空，避免空列表、空范围等被忽略的条件删除全部商品
+func DeleteProductsByFilter(f *filter.Filter, userID int) (int, error) {
+	clause, args, err := f.Compile(productSearchSchema(userID))
+	if err != nil {
+		return 0, err
+	}
+	if clause == "" {
+		return 0, &search.Error{Msg: "筛选条件不能为空"}
+	}
+	ids, err := queryIDs("SELECT id FROM cc_product WHERE "+clause, args...)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), DeleteProducts(ids, userID)
 }
 
 func GetProductByID(id, userID int) (*Product, error) {
-	p := &Product{}
-	err := database.DB.QueryRow(
-		`SELECT id, user_id, area_id, photo, customer_name, size, quantity, address, status_note_photo,
-		cost_eur, exchange_rate, cost_rmb, price_rmb, shipping_fee, total_cost, profit,
-		created_at, updated_at, mark, brand 
-		FROM cc_product WHERE id=?`,
+	p, err := scanProduct(database.DB.QueryRow(
+		`SELECT `+productColumns+` FROM cc_product WHERE id=?`,
 		id,
-	).Scan(
-		&p.ID, &p.UserID, &p.AreaID, &p.Photo, &p.CustomerName, &p.Size, &p.Quantity, &p.Address, &p.StatusNotePhoto,
-		&p.CostEur, &p.ExchangeRate, &p.CostRMB, &p.PriceRMB, &p.ShippingFee, &p.TotalCost, &p.Profit,
-		&p.CreatedAt, &p.UpdatedAt, &p.Mark, &p.Brand,
-	)
+	))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -236,121 +565,183 @@ func GetProductByID(id, userID int) (*Product, error) {
 	return p, nil
 }
 
-func GetProductList(userID, page, pageSize int, orderBy, orderDir, keyword, startTime, endTime string, areaID *int) (*ProductListResponse, error) {
-	// 验证排序字段
-	validOrderFields := map[string]bool{
-		"id": true, "customer_name": true, "size": true, "cost_eur": true,
-		"exchange_rate": true, "cost_rmb": true, "price_rmb": true,
-		"shipping_fee": true, "total_cost": true, "profit": true, "created_at": true, "updated_at": true,
+// productSortExprs 可排序字段及排序表达式，可能为 NULL 的列用 COALESCE 保证游标比较正确
+var productSortExprs = map[string]string{
+	"id": "id", "customer_name": "COALESCE(customer_name, '')", "size": "COALESCE(size, '')",
+	"cost_eur": "COALESCE(cost_eur, 0)", "exchange_rate": "COALESCE(exchange_rate, 0)",
+	"cost_rmb": "COALESCE(cost_rmb, 0)", "price_rmb": "COALESCE(price_rmb, 0)",
+	"shipping_fee": "COALESCE(shipping_fee, 0)", "total_cost": "COALESCE(total_cost, 0)",
+	"profit": "COALESCE(profit, 0)", "created_at": "created_at", "updated_at": "updated_at",
+}
+
+// productSortValue 取商品在排序列上的值，用于生成游标
+func productSortValue(p *Product, orderBy string) interface{} {
+	switch orderBy {
+	case "customer_name":
+		return p.CustomerName
+	case "size":
+		return p.Size
+	case "cost_eur":
+		return p.CostEur
+	case "exchange_rate":
+		return p.ExchangeRate
+	case "cost_rmb":
+		return p.CostRMB
+	case "price_rmb":
+		return p.PriceRMB
+	case "shipping_fee":
+		return p.ShippingFee
+	case "total_cost":
+		return p.TotalCost
+	case "profit":
+		return p.Profit
+	case "created_at":
+		return sortTimeValue(p.CreatedAt)
+	case "updated_at":
+		return sortTimeValue(p.UpdatedAt)
 	}
-	if orderBy == "" {
+	return p.ID
+}
+
+func GetProductList(userID int, opt ListOptions, f *filter.Filter) (*ProductListResponse, error) {
+	// 验证排序字段，非管理员不能按成本类字段排序（游标会带出字段值）
+	orderBy, orderDir := opt.OrderBy, opt.OrderDir
+	if _, ok := productSortExprs[orderBy]; !ok {
 		orderBy = "id"
 	}
-	if !validOrderFields[orderBy] {
+	if userID != 1 && isCostField(orderBy) {
 		orderBy = "id"
 	}
 	if orderDir != "ASC" && orderDir != "DESC" {
 		orderDir = "DESC"
 	}
+	sortExpr := productSortExprs[orderBy]
 
 	// 构建WHERE条件
 	// whereClause := "WHERE user_id=?"
 	// args := []interface{}{userID}
 
-	whereClause := "WHERE 1=1"
-	args := []interface{}{}
-
-	// 添加区域过滤
-	if areaID != nil {
-		whereClause += " AND area_id=?"
-		args = append(args, *areaID)
+	whereClause, args, err := ProductWhere(f, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if keyword != "" {
-		whereClause += " AND (customer_name LIKE ? OR size LIKE ? OR address LIKE ? OR mark LIKE ? OR cost_eur LIKE ? OR cost_rmb LIKE ? OR price_rmb LIKE ? OR shipping_fee LIKE ? OR total_cost LIKE ? OR profit LIKE ? OR brand LIKE ?)"
-		searchPattern := "%" + keyword + "%"
-		args = append(args, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern)
+	resp := &ProductListResponse{
+		Total:    -1,
+		Page:     opt.Page,
+		PageSize: opt.PageSize,
 	}
 
-	// 添加时间范围查询
-	if startTime != "" {
-		whereClause += " AND created_at >= ?"
-		args = append(args, startTime)
-	}
-	if endTime != "" {
-		whereClause += " AND created_at <= ?"
-		args = append(args, endTime)
+	// 首页统计总数和汇总，游标翻页时沿用首页结果
+	if opt.Cursor == "" {
+		if !opt.SkipTotal {
+			countQuery := fmt.Sprintf("SELECT COUNT(*) FROM cc_product %s", whereClause)
+			if err := database.DB.QueryRow(countQuery, args...).Scan(&resp.Total); err != nil {
+				return nil, err
+			}
+		}
+
+		summary, err := GetSummary(userID, whereClause, args)
+		if err != nil {
+			return nil, err
+		}
+		resp.Summary = summary
 	}
 
-	// 获取总数
-	var total int64
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM cc_product %s", whereClause)
-	err := database.DB.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, err
+	// 获取列表，多取一条判断是否还有下一页
+	pageWhere, pageArgs := whereClause, append([]interface{}{}, args...)
+	limitClause := "LIMIT ? OFFSET ?"
+	limitArgs := []interface{}{opt.PageSize + 1, (opt.Page - 1) * opt.PageSize}
+	if opt.Cursor != "" {
+		c, err := decodeCursor(opt.Cursor, orderBy, orderDir)
+		if err != nil {
+			return nil, err
+		}
+		clause, clauseArgs := keysetClause(sortExpr, c)
+		pageWhere += " AND " + clause
+		pageArgs = append(pageArgs, clauseArgs...)
+		limitClause = "LIMIT ?"
+		limitArgs = limitArgs[:1]
 	}
 
-	// 获取列表
-	offset := (page - 1) * pageSize
 	query := fmt.Sprintf(`
-		SELECT id, user_id, area_id, photo, customer_name, size, quantity, address, status_note_photo,
-		cost_eur, exchange_rate, cost_rmb, price_rmb, shipping_fee, total_cost, profit,
-		created_at, updated_at, mark,brand 
+		SELECT %s
 		FROM cc_product
 		%s
-		ORDER BY %s %s
-		LIMIT ? OFFSET ?
-	`, whereClause, orderBy, orderDir)
+		ORDER BY %s %s, id %s
+		%s
+	`, productColumns, pageWhere, sortExpr, orderDir, orderDir, limitClause)
 
-	queryArgs := append(args, pageSize, offset)
-	rows, err := database.DB.Query(query, queryArgs...)
+	rows, err := database.DB.Query(query, append(pageArgs, limitArgs...)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	list := []*Product{}
-	sid := 0
 	for rows.Next() {
-		p := &Product{}
-		err := rows.Scan(
-			&p.ID, &p.UserID, &p.AreaID, &p.Photo, &p.CustomerName, &p.Size, &p.Quantity, &p.Address, &p.StatusNotePhoto,
-			&p.CostEur, &p.ExchangeRate, &p.CostRMB, &p.PriceRMB, &p.ShippingFee, &p.TotalCost, &p.Profit,
-			&p.CreatedAt, &p.UpdatedAt, &p.Mark, &p.Brand,
-		)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
-		if userID != 1 {
-			p.CostEur = 0.0
-			p.ExchangeRate = 0.0
-			p.CostRMB = 0.0
-			p.TotalCost = 0.0
-			p.Profit = 0.0
-			p.ShippingFee = 0.0
-		}
-		sid++
-		p.SID = sid
 		list = append(list, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	// 获取汇总数据
-	summary, err := GetSummary(userID, whereClause, args)
-	if err != nil {
+	if len(list) > opt.PageSize {
+		list = list[:opt.PageSize]
+		resp.HasMore = true
+	}
+	if resp.HasMore && len(list) > 0 {
+		last := list[len(list)-1]
+		resp.NextCursor = encodeCursor(orderBy, orderDir, productSortValue(last, orderBy), last.ID)
+	}
+	for i, p := range list {
+		maskProductCost(p, userID)
+		p.SID = i + 1
+	}
+	if err := attachProductTags(list); err != nil {
 		return nil, err
 	}
+	resp.List = list
 
-	return &ProductListResponse{
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-		List:     list,
-		Summary:  summary,
-	}, nil
+	return resp, nil
+}
+
+// isCostField 是否为非管理员不可见的成本类字段
+func isCostField(field string) bool {
+	for _, f := range costFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// costFields 非管理员不能排序、搜索和筛选的成本与利润列
+var costFields = []string{"cost_eur", "exchange_rate", "cost_rmb", "shipping_fee", "total_cost", "profit"}
+
+// productSearchSchemaRestricted 非管理员使用的搜索字段，按成本列搜索会被拒绝，避免通过范围二分推出隐藏的金额
+var productSearchSchemaRestricted = ProductSearchSchema.Restrict(costFields...)
+
+// productSearchSchema 用户可用的商品搜索字段
+func productSearchSchema(userID int) *search.Schema {
+	if userID == 1 {
+		return ProductSearchSchema
+	}
+	return productSearchSchemaRestricted
+}
+
+// ProductWhere 将筛选条件编译为 cc_product 的 WHERE 子句，非管理员不能按成本列筛选
+func ProductWhere(f *filter.Filter, userID int) (string, []interface{}, error) {
+	return f.Where(productSearchSchema(userID), "WHERE 1=1", []interface{}{})
 }
 
 func GetSummary(userID int, whereClause string, args []interface{}) (*Summary, error) {
 	summary := &Summary{}
+	var refunded float64
 
 	query := fmt.Sprintf(`
 		SELECT
@@ -358,9 +749,15 @@ func GetSummary(userID int, whereClause string, args []interface{}) (*Summary, e
 			COALESCE(SUM(cost_rmb), 0),
 			COALESCE(SUM(price_rmb), 0),
 			COALESCE(SUM(shipping_fee), 0),
+			COALESCE(SUM(vat_refund), 0),
+			COALESCE(SUM(card_fee), 0),
+			COALESCE(SUM(commission), 0),
+			COALESCE(SUM(other_cost), 0),
 			COALESCE(SUM(total_cost), 0),
 			COALESCE(SUM(profit), 0),
-			COALESCE(SUM(quantity), 0)
+			COALESCE(SUM(quantity), 0),
+			COALESCE(SUM(paid_amount), 0),
+			COALESCE(SUM(refunded_amount), 0)
 		FROM cc_product
 		%s
 	`, whereClause)
@@ -370,20 +767,42 @@ func GetSummary(userID int, whereClause string, args []interface{}) (*Summary, e
 		&summary.TotalCostRMB,
 		&summary.TotalPriceRMB,
 		&summary.TotalShippingFee,
+		&summary.TotalVatRefund,
+		&summary.TotalCardFee,
+		&summary.TotalCommission,
+		&summary.TotalOtherCost,
 		&summary.TotalCost,
 		&summary.TotalProfit,
 		&summary.TotalQuantity,
+		&summary.TotalReceived,
+		&refunded,
 	)
 	if err != nil {
 		return nil, err
 	}
+	summary.TotalOutstanding = summary.TotalPriceRMB - refunded - summary.TotalReceived
+	summary.Returns, err = GetReturnSummary(userID, whereClause, args)
+	if err != nil {
+		return nil, err
+	}
+	summary.Tags, err = GetTagSummaries(userID, whereClause, args)
+	if err != nil {
+		return nil, err
+	}
+
 	if userID != 1 {
 		summary.TotalCostEur = 0.0
 		summary.TotalCostRMB = 0.0
 		summary.TotalPriceRMB = 0.0
 		summary.TotalShippingFee = 0.0
+		summary.TotalVatRefund = 0.0
+		summary.TotalCardFee = 0.0
+		summary.TotalCommission = 0.0
+		summary.TotalOtherCost = 0.0
 		summary.TotalCost = 0.0
 		summary.TotalProfit = 0.0
+		summary.TotalReceived = 0.0
+		summary.TotalOutstanding = 0.0
 	}
 	return summary, nil
 }
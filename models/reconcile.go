@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sorting-system/database"
+	"strings"
+)
+
+// ArrivalItem 到货明细，关联到货记录与商品
+type ArrivalItem struct {
+	ID        int      `json:"id"`
+	ArrivalID int      `json:"arrival_id"`
+	ProductID int      `json:"product_id"`
+	UserID    int      `json:"user_id"`
+	CreatedAt string   `json:"created_at"`
+	Product   *Product `json:"product,omitempty"`
+}
+
+// ReconcileReport 对账报告
+type ReconcileReport struct {
+	NotArrived        []*Product `json:"not_arrived"`
+	UnmatchedArrivals []*Arrival `json:"unmatched_arrivals"`
+}
+
+// ErrProductNotFound 要关联的商品不存在
+var ErrProductNotFound = errors.New("商品不存在")
+
+// AddArrivalItems 为到货记录关联商品，已关联的商品忽略
+// 有商品不存在时不做任何关联，返回包装了 ErrProductNotFound 的错误
+func AddArrivalItems(arrivalID, userID int, productIDs []int) error {
+	missing, err := missingIDs("cc_product", productIDs)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %v", ErrProductNotFound, missing)
+	}
+	for _, productID := range productIDs {
+		_, err := database.DB.Exec(
+			`INSERT IGNORE INTO cc_arrival_item (arrival_id, product_id, user_id) VALUES (?, ?, ?)`,
+			arrivalID, productID, userID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveArrivalItems 取消到货记录与商品的关联
+func RemoveArrivalItems(arrivalID int, productIDs []int) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(productIDs))
+	args := make([]interface{}, len(productIDs)+1)
+	args[0] = arrivalID
+
+	for i, id := range productIDs {
+		placeholders[i] = "?"
+		args[i+1] = id
+	}
+
+	query := fmt.Sprintf("DELETE FROM cc_arrival_item WHERE arrival_id=? AND product_id IN (%s)",
+		strings.Join(placeholders, ","))
+	_, err := database.DB.Exec(query, args...)
+	return err
+}
+
+// GetArrivalItems 获取到货记录下的明细及商品
+func GetArrivalItems(arrivalID, userID int) ([]*ArrivalItem, error) {
+	rows, err := database.DB.Query(
+		`SELECT id, arrival_id, product_id, user_id, created_at
+		FROM cc_arrival_item WHERE arrival_id=? ORDER BY id ASC`,
+		arrivalID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ArrivalItem, 0)
+	for rows.Next() {
+		item := &ArrivalItem{}
+		if err := rows.Scan(&item.ID, &item.ArrivalID, &item.ProductID, &item.UserID, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range list {
+		p, err := GetProductByID(item.ProductID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			maskProductCost(p, userID)
+		}
+		item.Product = p
+	}
+	return list, nil
+}
+
+// MatchArrivalCandidates 按品牌和日期窗口为到货记录推荐候选商品
+// days 为到货日期之前的天数窗口，已关联到任意到货记录的商品不再推荐
+func MatchArrivalCandidates(arrival *Arrival, userID, days int) ([]*Product, error) {
+	if days <= 0 {
+		days = 30
+	}
+	// 没有品牌时无法匹配，避免 LIKE '%%' 推荐窗口内的全部商品
+	brand := strings.TrimSpace(arrival.Brand)
+	if brand == "" {
+		return []*Product{}, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM cc_product
+		WHERE brand LIKE ?
+		AND created_at >= DATE_SUB(?, INTERVAL ? DAY)
+		AND created_at < DATE_ADD(?, INTERVAL 1 DAY)
+		AND NOT EXISTS (SELECT 1 FROM cc_arrival_item ai WHERE ai.product_id = cc_product.id)
+		ORDER BY created_at DESC
+	`, productColumns)
+
+	rows, err := database.DB.Query(query,
+		"%"+brand+"%",
+		sortTimeValue(arrival.ArrivalDate), days, sortTimeValue(arrival.ArrivalDate),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		maskProductCost(p, userID)
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// GetReconcileReport 生成对账报告：未到货的商品和未匹配订单的到货记录
+func GetReconcileReport(userID int, startTime, endTime string) (*ReconcileReport, error) {
+	report := &ReconcileReport{
+		NotArrived:        []*Product{},
+		UnmatchedArrivals: []*Arrival{},
+	}
+
+	// 未到货商品
+	whereClause := "WHERE NOT EXISTS (SELECT 1 FROM cc_arrival_item ai WHERE ai.product_id = cc_product.id)"
+	args := []interface{}{}
+	if startTime != "" {
+		whereClause += " AND created_at >= ?"
+		args = append(args, startTime)
+	}
+	if endTime != "" {
+		whereClause += " AND created_at <= ?"
+		args = append(args, endTime)
+	}
+
+	rows, err := database.DB.Query(
+		fmt.Sprintf("SELECT %s FROM cc_product %s ORDER BY created_at ASC", productColumns, whereClause),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		maskProductCost(p, userID)
+		report.NotArrived = append(report.NotArrived, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 未匹配的到货记录
+	whereClause = "WHERE user_id=? AND NOT EXISTS (SELECT 1 FROM cc_arrival_item ai WHERE ai.arrival_id = cc_arrival.id)"
+	args = []interface{}{userID}
+	if startTime != "" {
+		whereClause += " AND arrival_date >= ?"
+		args = append(args, startTime)
+	}
+	if endTime != "" {
+		whereClause += " AND arrival_date <= ?"
+		args = append(args, endTime)
+	}
+
+	arrivalRows, err := database.DB.Query(
+		fmt.Sprintf("SELECT %s FROM cc_arrival %s ORDER BY arrival_date ASC", arrivalColumns, whereClause),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer arrivalRows.Close()
+
+	for arrivalRows.Next() {
+		a, err := scanArrival(arrivalRows)
+		if err != nil {
+			return nil, err
+		}
+		report.UnmatchedArrivals = append(report.UnmatchedArrivals, a)
+	}
+	return report, arrivalRows.Err()
+}
@@ -0,0 +1,241 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sorting-system/database"
+	"time"
+)
+
+// ProductReturn 商品退货记录
+//
+// RefundToCustomer 退给客户的金额，RefundFromSupplier 供应商退给我们的金额，
+// RestockingFee 供应商收取的退货手续费；三者合计计入商品利润
+type ProductReturn struct {
+	ID                 int      `json:"id"`
+	ProductID          int      `json:"product_id"`
+	UserID             int      `json:"user_id"`
+	Reason             string   `json:"reason"`
+	RefundToCustomer   float64  `json:"refund_to_customer"`
+	RefundFromSupplier float64  `json:"refund_from_supplier"`
+	RestockingFee      float64  `json:"restocking_fee"`
+	Photos             []string `json:"photos"`
+	Note               string   `json:"note"`
+	ReturnedAt         string   `json:"returned_at"`
+	CreatedAt          string   `json:"created_at"`
+	UpdatedAt          string   `json:"updated_at"`
+}
+
+// ReturnSummary 退货汇总，NetImpact 为对利润的净影响
+type ReturnSummary struct {
+	Count              int     `json:"count"`
+	RefundToCustomer   float64 `json:"refund_to_customer"`
+	RefundFromSupplier float64 `json:"refund_from_supplier"`
+	RestockingFee      float64 `json:"restocking_fee"`
+	NetImpact          float64 `json:"net_impact"`
+}
+
+const returnColumns = `id, product_id, user_id, reason, refund_to_customer, refund_from_supplier, restocking_fee,
+		photos, note, returned_at, created_at, updated_at`
+
+func scanReturn(row rowScanner) (*ProductReturn, error) {
+	r := &ProductReturn{}
+	var photos string
+	err := row.Scan(&r.ID, &r.ProductID, &r.UserID, &r.Reason, &r.RefundToCustomer, &r.RefundFromSupplier,
+		&r.RestockingFee, &photos, &r.Note, &r.ReturnedAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.ReturnedAt = sortTimeValue(r.ReturnedAt)
+	r.Photos = []string{}
+	if photos != "" {
+		if err := json.Unmarshal([]byte(photos), &r.Photos); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func marshalPhotos(photos []string) (string, error) {
+	if photos == nil {
+		photos = []string{}
+	}
+	b, err := json.Marshal(photos)
+	return string(b), err
+}
+
+// CreateReturn 登记退货并重算商品利润
+func CreateReturn(r *ProductReturn) error {
+	if err := checkProductsOpen([]int{r.ProductID}); err != nil {
+		return err
+	}
+	photos, err := marshalPhotos(r.Photos)
+	if err != nil {
+		return err
+	}
+	if r.ReturnedAt == "" {
+		r.ReturnedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	result, err := database.DB.Exec(
+		`INSERT INTO cc_product_return
+		(product_id, user_id, reason, refund_to_customer, refund_from_supplier, restocking_fee, photos, note, returned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ProductID, r.UserID, r.Reason, r.RefundToCustomer, r.RefundFromSupplier, r.RestockingFee, photos, r.Note, r.ReturnedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	r.ID = int(id)
+	return applyReturns(r.ProductID)
+}
+
+// UpdateReturn 修改退货记录，记录不存在时返回 sql.ErrNoRows
+func UpdateReturn(r *ProductReturn) error {
+	if err := checkProductsOpen([]int{r.ProductID}); err != nil {
+		return err
+	}
+	old, err := GetReturnByID(r.ID, r.ProductID)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return sql.ErrNoRows
+	}
+	photos, err := marshalPhotos(r.Photos)
+	if err != nil {
+		return err
+	}
+	if r.ReturnedAt == "" {
+		r.ReturnedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	_, err = database.DB.Exec(
+		`UPDATE cc_product_return SET reason=?, refund_to_customer=?, refund_from_supplier=?, restocking_fee=?,
+		photos=?, note=?, returned_at=?
+		WHERE id=? AND product_id=?`,
+		r.Reason, r.RefundToCustomer, r.RefundFromSupplier, r.RestockingFee, photos, r.Note, r.ReturnedAt,
+		r.ID, r.ProductID,
+	)
+	if err != nil {
+		return err
+	}
+	return applyReturns(r.ProductID)
+}
+
+// DeleteReturn 删除退货记录，记录不存在时返回 sql.ErrNoRows
+func DeleteReturn(id, productID int) error {
+	if err := checkProductsOpen([]int{productID}); err != nil {
+		return err
+	}
+	result, err := database.DB.Exec(`DELETE FROM cc_product_return WHERE id=? AND product_id=?`, id, productID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return applyReturns(productID)
+}
+
+// GetReturnByID 获取商品下的退货记录
+func GetReturnByID(id, productID int) (*ProductReturn, error) {
+	r, err := scanReturn(database.DB.QueryRow(
+		`SELECT `+returnColumns+` FROM cc_product_return WHERE id=? AND product_id=?`,
+		id, productID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetReturnList 获取商品的退货记录
+func GetReturnList(productID int) ([]*ProductReturn, error) {
+	rows, err := database.DB.Query(
+		`SELECT `+returnColumns+` FROM cc_product_return WHERE product_id=? ORDER BY returned_at ASC, id ASC`,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ProductReturn, 0)
+	for rows.Next() {
+		r, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// applyReturns 汇总商品的退货金额，重算利润与客户应付金额
+func applyReturns(productID int) error {
+	_, err := database.DB.Exec(
+		`UPDATE cc_product SET
+		refunded_amount = (SELECT COALESCE(SUM(refund_to_customer), 0) FROM cc_product_return WHERE product_id = cc_product.id),
+		return_adjustment = (SELECT COALESCE(SUM(refund_from_supplier - restocking_fee - refund_to_customer), 0)
+			FROM cc_product_return WHERE product_id = cc_product.id)
+		WHERE id=?`,
+		productID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := recalculateProduct(database.DB, productID, nil); err != nil {
+		return err
+	}
+
+	var customerName string
+	err = database.DB.QueryRow(`SELECT COALESCE(customer_name, '') FROM cc_product WHERE id=?`, productID).Scan(&customerName)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return SyncCustomerPayments(customerName)
+}
+
+// GetReturnSummary 汇总符合条件商品的退货，whereClause 作用于 cc_product
+func GetReturnSummary(userID int, whereClause string, args []interface{}) (*ReturnSummary, error) {
+	s := &ReturnSummary{}
+	query := fmt.Sprintf(`
+		SELECT COUNT(*),
+			COALESCE(SUM(r.refund_to_customer), 0),
+			COALESCE(SUM(r.refund_from_supplier), 0),
+			COALESCE(SUM(r.restocking_fee), 0)
+		FROM cc_product_return r
+		JOIN (SELECT id FROM cc_product %s) p ON p.id = r.product_id
+	`, whereClause)
+
+	err := database.DB.QueryRow(query, args...).Scan(&s.Count, &s.RefundToCustomer, &s.RefundFromSupplier, &s.RestockingFee)
+	if err != nil {
+		return nil, err
+	}
+	s.NetImpact = s.RefundFromSupplier - s.RestockingFee - s.RefundToCustomer
+
+	if userID != 1 {
+		s.RefundToCustomer = 0.0
+		s.RefundFromSupplier = 0.0
+		s.RestockingFee = 0.0
+		s.NetImpact = 0.0
+	}
+	return s, nil
+}
@@ -0,0 +1,394 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sorting-system/database"
+	"strings"
+)
+
+// 扫码匹配方式
+const (
+	ScanMatchProduct  = "product"  // 商品ID
+	ScanMatchBox      = "box"      // 到货箱号
+	ScanMatchTracking = "tracking" // 运单号
+	ScanMatchNone     = ""         // 未找到
+)
+
+// ScanProduct 扫码结果中的商品，只包含分拣需要的字段
+type ScanProduct struct {
+	ID           int     `json:"id"`
+	CustomerName string  `json:"customer_name"`
+	AreaID       int     `json:"area_id"`
+	AreaName     string  `json:"area_name"`
+	Brand        string  `json:"brand"`
+	Size         string  `json:"size"`
+	Quantity     int     `json:"quantity"`
+	SortedAt     *string `json:"sorted_at"`
+	// Unexpected 会话有范围且商品不在范围内
+	Unexpected bool `json:"unexpected"`
+}
+
+// ScanResult 一次扫码的结果；箱号和运单号可能对应多件商品，此时不自动标记分拣
+type ScanResult struct {
+	EventID   int            `json:"event_id"`
+	Code      string         `json:"code"`
+	MatchedBy string         `json:"matched_by"`
+	Products  []*ScanProduct `json:"products"`
+	Sorted    bool           `json:"sorted"`
+}
+
+// ScanSession 分拣会话，ArrivalID 或 ShipmentID 非0时其中的商品为应扫商品
+type ScanSession struct {
+	ID         int     `json:"id"`
+	UserID     int     `json:"user_id"`
+	Name       string  `json:"name"`
+	ArrivalID  int     `json:"arrival_id"`
+	ShipmentID int     `json:"shipment_id"`
+	ClosedAt   *string `json:"closed_at"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// SessionAreaSummary 会话内单个区域的应扫与已扫件数
+type SessionAreaSummary struct {
+	AreaID   int    `json:"area_id"`
+	AreaName string `json:"area_name"`
+	Expected int    `json:"expected"`
+	Scanned  int    `json:"scanned"`
+	Missing  int    `json:"missing"`
+	Extra    int    `json:"extra"`
+}
+
+// SessionSummary 分拣会话汇总
+type SessionSummary struct {
+	Session  *ScanSession          `json:"session"`
+	Areas    []*SessionAreaSummary `json:"areas"`
+	Expected int                   `json:"expected"`
+	Scanned  int                   `json:"scanned"`
+	Missing  int                   `json:"missing"`
+	Extra    int                   `json:"extra"`
+	Scans    int                   `json:"scans"`
+}
+
+// ErrSessionClosed 会话已结束
+var ErrSessionClosed = fmt.Errorf("分拣会话已结束")
+
+const scanProductColumns = `p.id, COALESCE(p.customer_name, ''), COALESCE(p.area_id, 0), COALESCE(a.name, ''),
+		COALESCE(p.brand, ''), COALESCE(p.size, ''), COALESCE(p.quantity, 0), p.sorted_at`
+
+// queryScanProducts 查询商品及其区域名称并按区域、客户排序，joinClause 与 whereClause 作用于 cc_product p
+func queryScanProducts(joinClause, whereClause string, args ...interface{}) ([]*ScanProduct, error) {
+	rows, err := database.DB.Query(
+		fmt.Sprintf(`SELECT DISTINCT %s FROM cc_product p
+		LEFT JOIN cc_product_area a ON a.id = p.area_id
+		%s %s ORDER BY 3, 2, 1`, scanProductColumns, joinClause, whereClause),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ScanProduct, 0)
+	for rows.Next() {
+		p := &ScanProduct{}
+		err := rows.Scan(&p.ID, &p.CustomerName, &p.AreaID, &p.AreaName, &p.Brand, &p.Size, &p.Quantity, &p.SortedAt)
+		if err != nil {
+			return nil, err
+		}
+		if p.SortedAt != nil {
+			s := sortTimeValue(*p.SortedAt)
+			p.SortedAt = &s
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// resolveScanCode 依次按商品条码、到货箱号、运单号查找商品
+//
+// 商品条码必须带 ProductCodePrefix，纯数字的箱号、运单号不会误匹配到同号的商品；箱号只匹配自己的到货记录
+func resolveScanCode(code string, userID int) (string, []*ScanProduct, error) {
+	if id, ok := ParseProductCode(code); ok {
+		list, err := queryScanProducts("", "WHERE p.id=?", id)
+		if err != nil || len(list) > 0 {
+			return ScanMatchProduct, list, err
+		}
+	}
+
+	list, err := queryScanProducts(
+		"JOIN cc_arrival_item ai ON ai.product_id = p.id JOIN cc_arrival ar ON ar.id = ai.arrival_id",
+		"WHERE ar.box_number=? AND ar.user_id=?", code, userID,
+	)
+	if err != nil || len(list) > 0 {
+		return ScanMatchBox, list, err
+	}
+
+	list, err = queryScanProducts(
+		"JOIN cc_shipment_item si ON si.product_id = p.id JOIN cc_shipment s ON s.id = si.shipment_id",
+		"WHERE s.tracking_no=?", code,
+	)
+	if err != nil || len(list) > 0 {
+		return ScanMatchTracking, list, err
+	}
+	return ScanMatchNone, list, nil
+}
+
+// Scan 处理一次扫码：解析商品、按需标记为已分拣、更新拣货单进度并记录扫码事件
+//
+// markSorted 只在唯一匹配到一件商品时生效；sessionID 为0表示不属于任何会话
+func Scan(code string, userID, sessionID int, markSorted bool) (*ScanResult, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("条码不能为空")
+	}
+
+	var session *ScanSession
+	if sessionID > 0 {
+		s, err := GetScanSessionByID(sessionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			return nil, sql.ErrNoRows
+		}
+		if s.ClosedAt != nil {
+			return nil, ErrSessionClosed
+		}
+		session = s
+	}
+
+	matchedBy, products, err := resolveScanCode(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := &ScanResult{Code: code, MatchedBy: matchedBy, Products: products}
+
+	productID, areaID := 0, 0
+	if len(products) == 1 {
+		p := products[0]
+		productID, areaID = p.ID, p.AreaID
+		if err := markPickListScanned(p.ID); err != nil {
+			return nil, err
+		}
+		if markSorted {
+			if _, err := database.DB.Exec(`UPDATE cc_product SET sorted_at=NOW() WHERE id=? AND sorted_at IS NULL`, p.ID); err != nil {
+				return nil, err
+			}
+			result.Sorted = true
+		}
+	}
+
+	if session != nil && (session.ArrivalID > 0 || session.ShipmentID > 0) {
+		expected, err := sessionExpectedIDs(session)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			p.Unexpected = !expected[p.ID]
+		}
+	}
+
+	res, err := database.DB.Exec(
+		`INSERT INTO cc_scan_event (session_id, user_id, code, matched_by, product_id, area_id, sorted)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sessionID, userID, code, matchedBy, productID, areaID, result.Sorted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	result.EventID = int(id)
+	return result, nil
+}
+
+func scanSession(row rowScanner) (*ScanSession, error) {
+	s := &ScanSession{}
+	if err := row.Scan(&s.ID, &s.UserID, &s.Name, &s.ArrivalID, &s.ShipmentID, &s.ClosedAt, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+const scanSessionColumns = `id, user_id, name, arrival_id, shipment_id, closed_at, created_at`
+
+// CreateScanSession 开始分拣会话，应扫范围只能是自己的到货记录或运单，管理员可使用任意运单
+func CreateScanSession(s *ScanSession) error {
+	if s.ArrivalID > 0 {
+		arrival, err := GetArrivalByID(s.ArrivalID, s.UserID)
+		if err != nil {
+			return err
+		}
+		if arrival == nil {
+			return fmt.Errorf("到货记录不存在")
+		}
+	}
+	if s.ShipmentID > 0 {
+		shipment, err := GetShipmentByID(s.ShipmentID)
+		if err != nil {
+			return err
+		}
+		if shipment == nil || (s.UserID != 1 && shipment.UserID != s.UserID) {
+			return fmt.Errorf("运单不存在")
+		}
+	}
+
+	result, err := database.DB.Exec(
+		`INSERT INTO cc_scan_session (user_id, name, arrival_id, shipment_id) VALUES (?, ?, ?, ?)`,
+		s.UserID, s.Name, s.ArrivalID, s.ShipmentID,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = int(id)
+	return nil
+}
+
+// CloseScanSession 结束分拣会话，之后不能再向会话扫码；会话不存在或属于他人时返回 sql.ErrNoRows
+func CloseScanSession(id, userID int) error {
+	s, err := GetScanSessionByID(id, userID)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return sql.ErrNoRows
+	}
+	_, err = database.DB.Exec(`UPDATE cc_scan_session SET closed_at=NOW() WHERE id=? AND closed_at IS NULL`, id)
+	return err
+}
+
+// GetScanSessionByID 根据ID获取分拣会话，非管理员只能获取自己的会话
+func GetScanSessionByID(id, userID int) (*ScanSession, error) {
+	s, err := scanSession(database.DB.QueryRow(`SELECT `+scanSessionColumns+` FROM cc_scan_session WHERE id=?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID != 1 && s.UserID != userID {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// GetScanSessionList 获取最近的分拣会话，非管理员只能看到自己的会话
+func GetScanSessionList(userID int) ([]*ScanSession, error) {
+	query, args := `SELECT `+scanSessionColumns+` FROM cc_scan_session`, []interface{}{}
+	if userID != 1 {
+		query += " WHERE user_id=?"
+		args = append(args, userID)
+	}
+	rows, err := database.DB.Query(query+" ORDER BY id DESC LIMIT 100", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ScanSession, 0)
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// sessionExpectedProducts 会话范围内应扫的商品，会话没有范围时为空
+func sessionExpectedProducts(s *ScanSession) ([]*ScanProduct, error) {
+	switch {
+	case s.ArrivalID > 0:
+		return queryScanProducts("JOIN cc_arrival_item ai ON ai.product_id = p.id", "WHERE ai.arrival_id=?", s.ArrivalID)
+	case s.ShipmentID > 0:
+		return queryScanProducts("JOIN cc_shipment_item si ON si.product_id = p.id", "WHERE si.shipment_id=?", s.ShipmentID)
+	}
+	return []*ScanProduct{}, nil
+}
+
+func sessionExpectedIDs(s *ScanSession) (map[int]bool, error) {
+	list, err := sessionExpectedProducts(s)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int]bool, len(list))
+	for _, p := range list {
+		ids[p.ID] = true
+	}
+	return ids, nil
+}
+
+// GetSessionSummary 按区域汇总会话的应扫与已扫件数，同一商品多次扫码只计一次
+func GetSessionSummary(id, userID int) (*SessionSummary, error) {
+	s, err := GetScanSessionByID(id, userID)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	summary := &SessionSummary{Session: s, Areas: []*SessionAreaSummary{}}
+
+	expected, err := sessionExpectedProducts(s)
+	if err != nil {
+		return nil, err
+	}
+	scanned, err := queryScanProducts(
+		"JOIN (SELECT DISTINCT product_id FROM cc_scan_event WHERE session_id=? AND product_id > 0) e ON e.product_id = p.id",
+		"", id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM cc_scan_event WHERE session_id=?`, id).Scan(&summary.Scans); err != nil {
+		return nil, err
+	}
+
+	byArea := map[int]*SessionAreaSummary{}
+	area := func(p *ScanProduct) *SessionAreaSummary {
+		a, ok := byArea[p.AreaID]
+		if !ok {
+			a = &SessionAreaSummary{AreaID: p.AreaID, AreaName: p.AreaName}
+			if a.AreaName == "" {
+				a.AreaName = "未分区"
+			}
+			byArea[p.AreaID] = a
+			summary.Areas = append(summary.Areas, a)
+		}
+		return a
+	}
+
+	expectedIDs := make(map[int]bool, len(expected))
+	for _, p := range expected {
+		expectedIDs[p.ID] = true
+		area(p).Expected++
+	}
+	scannedIDs := make(map[int]bool, len(scanned))
+	for _, p := range scanned {
+		scannedIDs[p.ID] = true
+		a := area(p)
+		a.Scanned++
+		if len(expected) > 0 && !expectedIDs[p.ID] {
+			a.Extra++
+		}
+	}
+	for _, p := range expected {
+		if !scannedIDs[p.ID] {
+			area(p).Missing++
+		}
+	}
+
+	for _, a := range summary.Areas {
+		summary.Expected += a.Expected
+		summary.Scanned += a.Scanned
+		summary.Missing += a.Missing
+		summary.Extra += a.Extra
+	}
+	return summary, nil
+}
@@ -0,0 +1,541 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sorting-system/database"
+)
+
+// 运费分摊方式
+const (
+	AllocateByQuantity = "quantity"
+	AllocateByWeight   = "weight"
+	AllocateByValue    = "value"
+	AllocateByManual   = "manual"
+)
+
+// ValidAllocateMethods 支持的分摊方式
+var ValidAllocateMethods = map[string]bool{
+	AllocateByQuantity: true,
+	AllocateByWeight:   true,
+	AllocateByValue:    true,
+	AllocateByManual:   true,
+}
+
+// Shipment 合并发货的一票国际运输，Freight 与 Customs 按 Method 分摊到商品的 shipping_fee
+type Shipment struct {
+	ID          int             `json:"id"`
+	UserID      int             `json:"user_id"`
+	Name        string          `json:"name"`
+	TrackingNo  string          `json:"tracking_no"`
+	Freight     float64         `json:"freight"`
+	Customs     float64         `json:"customs"`
+	Method      string          `json:"method"`
+	Note        string          `json:"note"`
+	AllocatedAt *string         `json:"allocated_at"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+	Items       []*ShipmentItem `json:"items,omitempty"`
+}
+
+// ShipmentItem 运单中的商品，Weight 和 Ratio 分别用于按重量和手动比例分摊
+//
+// Weight 未填写时取商品的计费重量
+type ShipmentItem struct {
+	ShipmentID   int     `json:"shipment_id"`
+	ProductID    int     `json:"product_id"`
+	Weight       float64 `json:"weight"`
+	Ratio        float64 `json:"ratio"`
+	Allocated    float64 `json:"allocated"`
+	CustomerName string  `json:"customer_name"`
+	Brand        string  `json:"brand"`
+	Quantity     int     `json:"quantity"`
+	PriceRMB     float64 `json:"price_rmb"`
+}
+
+const shipmentColumns = `id, user_id, name, tracking_no, freight, customs, method, note, allocated_at, created_at, updated_at`
+
+func scanShipment(row rowScanner) (*Shipment, error) {
+	s := &Shipment{}
+	var allocatedAt sql.NullString
+	err := row.Scan(&s.ID, &s.UserID, &s.Name, &s.TrackingNo, &s.Freight, &s.Customs, &s.Method, &s.Note,
+		&allocatedAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if allocatedAt.Valid {
+		s.AllocatedAt = &allocatedAt.String
+	}
+	return s, nil
+}
+
+// CreateShipment 创建运单
+func CreateShipment(s *Shipment) error {
+	if !ValidAllocateMethods[s.Method] {
+		s.Method = AllocateByQuantity
+	}
+	result, err := database.DB.Exec(
+		`INSERT INTO cc_shipment (user_id, name, tracking_no, freight, customs, method, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.UserID, s.Name, s.TrackingNo, s.Freight, s.Customs, s.Method, s.Note,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = int(id)
+	return nil
+}
+
+// UpdateShipment 更新运单信息与费用
+//
+// 已分摊过的运单修改运费、清关费或分摊方式时，运单商品所属月份已结账则返回 ErrPeriodClosed
+func UpdateShipment(s *Shipment) error {
+	if !ValidAllocateMethods[s.Method] {
+		s.Method = AllocateByQuantity
+	}
+	old, err := GetShipmentByID(s.ID)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return sql.ErrNoRows
+	}
+	if old.AllocatedAt != nil && (old.Freight != s.Freight || old.Customs != s.Customs || old.Method != s.Method) {
+		ids, err := queryIDs(`SELECT product_id FROM cc_shipment_item WHERE shipment_id=?`, s.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkProductsOpen(ids); err != nil {
+			return err
+		}
+	}
+	_, err = database.DB.Exec(
+		`UPDATE cc_shipment SET name=?, tracking_no=?, freight=?, customs=?, method=?, note=? WHERE id=?`,
+		s.Name, s.TrackingNo, s.Freight, s.Customs, s.Method, s.Note, s.ID,
+	)
+	return err
+}
+
+// DeleteShipment 删除运单，已分摊过的运单清除商品上分摊的运费并重新估算
+//
+// 商品所属月份已结账时返回 ErrPeriodClosed
+func DeleteShipment(id int) error {
+	s, err := GetShipmentByID(id)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return sql.ErrNoRows
+	}
+	ids, err := queryIDs(`SELECT product_id FROM cc_shipment_item WHERE shipment_id=?`, id)
+	if err != nil {
+		return err
+	}
+	allocated := s.AllocatedAt != nil
+	if allocated {
+		if err := checkProductsOpen(ids); err != nil {
+			return err
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM cc_shipment WHERE id=?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM cc_shipment_item WHERE shipment_id=?`, id); err != nil {
+		return err
+	}
+	if allocated {
+		if err := resetShippingFees(tx, ids); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetShipmentByID 根据ID获取运单
+func GetShipmentByID(id int) (*Shipment, error) {
+	s, err := scanShipment(database.DB.QueryRow(
+		`SELECT `+shipmentColumns+` FROM cc_shipment WHERE id=?`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetShipmentList 获取运单列表
+func GetShipmentList() ([]*Shipment, error) {
+	rows, err := database.DB.Query(`SELECT ` + shipmentColumns + ` FROM cc_shipment ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Shipment, 0)
+	for rows.Next() {
+		s, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// AddShipmentItems 将商品加入运单，返回未加入的商品ID（不存在或已在其他运单中）
+func AddShipmentItems(shipmentID int, productIDs []int) ([]int, error) {
+	if len(productIDs) == 0 {
+		return []int{}, nil
+	}
+	args := make([]interface{}, 0, len(productIDs)+1)
+	args = append(args, shipmentID)
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	addable, err := queryIDs(
+		fmt.Sprintf(`SELECT p.id FROM cc_product p
+		WHERE NOT EXISTS (SELECT 1 FROM cc_shipment_item si WHERE si.product_id = p.id AND si.shipment_id <> ?)
+		AND p.id IN (%s)`, placeholders(len(productIDs))),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ok := make(map[int]bool, len(addable))
+	for _, productID := range addable {
+		ok[productID] = true
+		_, err := database.DB.Exec(
+			`INSERT IGNORE INTO cc_shipment_item (shipment_id, product_id) VALUES (?, ?)`,
+			shipmentID, productID,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	skipped := make([]int, 0)
+	for _, id := range productIDs {
+		if !ok[id] {
+			skipped = append(skipped, id)
+		}
+	}
+	return skipped, nil
+}
+
+// RemoveShipmentItems 将商品移出运单
+//
+// 已分摊过的运单清除移出商品上分摊的运费并重新估算，剩余商品重新分摊；
+// 涉及的商品所属月份已结账时返回 ErrPeriodClosed
+func RemoveShipmentItems(shipmentID int, productIDs []int) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	s, err := GetShipmentByID(shipmentID)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return sql.ErrNoRows
+	}
+	items, err := GetShipmentItems(shipmentID)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[int]bool, len(productIDs))
+	for _, id := range productIDs {
+		remove[id] = true
+	}
+	var removed, remaining []int
+	for _, item := range items {
+		if remove[item.ProductID] {
+			removed = append(removed, item.ProductID)
+		} else {
+			remaining = append(remaining, item.ProductID)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	allocated := s.AllocatedAt != nil
+	if allocated {
+		if err := checkProductsOpen(append(append([]int{}, removed...), remaining...)); err != nil {
+			return err
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := make([]interface{}, 0, len(removed)+1)
+	args = append(args, shipmentID)
+	for _, id := range removed {
+		args = append(args, id)
+	}
+	if _, err := tx.Exec(
+		fmt.Sprintf("DELETE FROM cc_shipment_item WHERE shipment_id=? AND product_id IN (%s)", placeholders(len(removed))),
+		args...,
+	); err != nil {
+		return err
+	}
+	if allocated {
+		if err := resetShippingFees(tx, removed); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if allocated && len(remaining) > 0 {
+		_, err = AllocateShipment(shipmentID)
+		return err
+	}
+	return nil
+}
+
+// allocatedShipmentsOf 包含这些商品且已分摊过的运单，商品所属运单中有已结账月份的商品时返回 ErrPeriodClosed
+//
+// 用于商品合并等会删除运单明细的操作，操作完成后调用 reallocateShipments 重新分摊
+func allocatedShipmentsOf(productIDs []int) ([]int, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		args[i] = id
+	}
+	shipmentIDs, err := queryIDs(
+		fmt.Sprintf(`SELECT DISTINCT si.shipment_id FROM cc_shipment_item si
+		JOIN cc_shipment s ON s.id = si.shipment_id
+		WHERE s.allocated_at IS NOT NULL AND si.product_id IN (%s)`, placeholders(len(productIDs))),
+		args...,
+	)
+	if err != nil || len(shipmentIDs) == 0 {
+		return nil, err
+	}
+
+	args = make([]interface{}, len(shipmentIDs))
+	for i, id := range shipmentIDs {
+		args[i] = id
+	}
+	items, err := queryIDs(
+		fmt.Sprintf(`SELECT product_id FROM cc_shipment_item WHERE shipment_id IN (%s)`, placeholders(len(shipmentIDs))),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProductsOpen(items); err != nil {
+		return nil, err
+	}
+	return shipmentIDs, nil
+}
+
+// reallocateShipments 按运单当前的商品重新分摊
+func reallocateShipments(shipmentIDs []int) error {
+	for _, id := range shipmentIDs {
+		if _, err := AllocateShipment(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetShippingFees 清除商品上分摊的运费，取消运费确认并按重量、尺寸重新估算
+func resetShippingFees(db sqlRunner, ids []int) error {
+	for _, id := range ids {
+		if err := recalculateProduct(db, id, func(p *Product) {
+			p.ShippingFee = 0
+			p.ShippingConfirmed = false
+			applyShippingEstimate(p)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateShipmentItem 设置运单商品的重量和手动分摊比例，商品不在运单中时返回 sql.ErrNoRows
+func UpdateShipmentItem(item *ShipmentItem) error {
+	var count int
+	err := database.DB.QueryRow(
+		`SELECT COUNT(*) FROM cc_shipment_item WHERE shipment_id=? AND product_id=?`,
+		item.ShipmentID, item.ProductID,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = database.DB.Exec(
+		`UPDATE cc_shipment_item SET weight=?, ratio=? WHERE shipment_id=? AND product_id=?`,
+		item.Weight, item.Ratio, item.ShipmentID, item.ProductID,
+	)
+	return err
+}
+
+// GetShipmentItems 获取运单中的商品
+func GetShipmentItems(shipmentID int) ([]*ShipmentItem, error) {
+	rows, err := database.DB.Query(
+		`SELECT si.shipment_id, si.product_id, si.weight, si.ratio, si.allocated,
+			COALESCE(p.customer_name, ''), COALESCE(p.brand, ''), COALESCE(p.quantity, 0), COALESCE(p.price_rmb, 0),
+			p.weight, p.length, p.width, p.height
+		FROM cc_shipment_item si
+		JOIN cc_product p ON p.id = si.product_id
+		WHERE si.shipment_id=?
+		ORDER BY si.product_id ASC`,
+		shipmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*ShipmentItem, 0)
+	for rows.Next() {
+		item := &ShipmentItem{}
+		var weight, length, width, height float64
+		err := rows.Scan(&item.ShipmentID, &item.ProductID, &item.Weight, &item.Ratio, &item.Allocated,
+			&item.CustomerName, &item.Brand, &item.Quantity, &item.PriceRMB, &weight, &length, &width, &height)
+		if err != nil {
+			return nil, err
+		}
+		if item.Weight == 0 {
+			item.Weight = ChargeableWeight(weight, length, width, height)
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// allocationBasis 商品按分摊方式对应的基数
+func allocationBasis(method string, item *ShipmentItem) float64 {
+	switch method {
+	case AllocateByWeight:
+		return item.Weight
+	case AllocateByValue:
+		return item.PriceRMB
+	case AllocateByManual:
+		return item.Ratio
+	}
+	return float64(item.Quantity)
+}
+
+// splitAmount 按基数比例拆分金额到分，尾差计入基数最大的一项，保证合计等于 total
+func splitAmount(total float64, bases []float64) ([]float64, error) {
+	sum := 0.0
+	largest := 0
+	for i, b := range bases {
+		if b < 0 {
+			return nil, fmt.Errorf("分摊基数不能为负数")
+		}
+		sum += b
+		if b > bases[largest] {
+			largest = i
+		}
+	}
+	if sum == 0 {
+		return nil, fmt.Errorf("分摊基数合计为0，请先填写数量、重量、售价或比例")
+	}
+
+	totalCents := math.Round(total * 100)
+	shares := make([]float64, len(bases))
+	assigned := 0.0
+	for i, b := range bases {
+		shares[i] = math.Floor(totalCents * b / sum)
+		assigned += shares[i]
+	}
+	shares[largest] += totalCents - assigned
+
+	for i := range shares {
+		shares[i] /= 100
+	}
+	return shares, nil
+}
+
+// AllocateShipment 将运单的运费与清关费分摊到商品，写入 shipping_fee 并重算总成本与利润
+//
+// 分摊后的运费为实际运费，商品的运费标记为已确认，不再自动估算
+//
+// 可重复执行，费用或商品变化后重新分摊即可覆盖上次结果
+func AllocateShipment(id int) ([]*ShipmentItem, error) {
+	s, err := GetShipmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	items, err := GetShipmentItems(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	ids := make([]int, len(items))
+	bases := make([]float64, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+		bases[i] = allocationBasis(s.Method, item)
+	}
+	if err := checkProductsOpen(ids); err != nil {
+		return nil, err
+	}
+
+	shares, err := splitAmount(s.Freight+s.Customs, bases)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for i, item := range items {
+		item.Allocated = shares[i]
+		if _, err := tx.Exec(
+			`UPDATE cc_shipment_item SET allocated=? WHERE shipment_id=? AND product_id=?`,
+			item.Allocated, id, item.ProductID,
+		); err != nil {
+			return nil, err
+		}
+		allocated := item.Allocated
+		if err := recalculateProduct(tx, item.ProductID, func(p *Product) {
+			p.ShippingFee = allocated
+			p.ShippingConfirmed = true
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE cc_shipment SET allocated_at=NOW() WHERE id=?`, id); err != nil {
+		return nil, err
+	}
+	return items, tx.Commit()
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitAmount(t *testing.T) {
+	tests := []struct {
+		name  string
+		total float64
+		bases []float64
+		want  []float64
+	}{
+		{"even split", 90, []float64{1, 1, 1}, []float64{30, 30, 30}},
+		{"remainder to first largest", 100, []float64{1, 1, 1}, []float64{33.34, 33.33, 33.33}},
+		{"remainder to largest base", 10, []float64{1, 2}, []float64{3.33, 6.67}},
+		{"zero base gets nothing", 50, []float64{0, 3, 1}, []float64{0, 37.5, 12.5}},
+		{"total rounded to cents", 0.015, []float64{1, 1}, []float64{0.01, 0.01}},
+		{"single item", 12.34, []float64{5}, []float64{12.34}},
+	}
+	for _, tt := range tests {
+		got, err := splitAmount(tt.total, tt.bases)
+		if err != nil {
+			t.Errorf("%s: splitAmount(%v, %v) error: %v", tt.name, tt.total, tt.bases, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitAmount(%v, %v) = %v, want %v", tt.name, tt.total, tt.bases, got, tt.want)
+		}
+	}
+}
+
+func TestSplitAmountErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		bases []float64
+	}{
+		{"zero sum", []float64{0, 0}},
+		{"negative base", []float64{2, -1}},
+	}
+	for _, tt := range tests {
+		if _, err := splitAmount(10, tt.bases); err == nil {
+			t.Errorf("%s: splitAmount(10, %v) want error", tt.name, tt.bases)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"sorting-system/database"
+	"strings"
+)
+
+// StatementItem 对账单明细，只包含可以给客户看的字段
+type StatementItem struct {
+	ID         int     `json:"id"`
+	CreatedAt  string  `json:"created_at"`
+	Photo      string  `json:"photo"`
+	Brand      string  `json:"brand"`
+	Size       string  `json:"size"`
+	Quantity   int     `json:"quantity"`
+	PriceRMB   float64 `json:"price_rmb"`
+	Refunded   float64 `json:"refunded"`
+	PaidAmount float64 `json:"paid_amount"`
+	Paid       bool    `json:"paid"`
+}
+
+// Statement 客户对账单
+type Statement struct {
+	CustomerName  string           `json:"customer_name"`
+	StartTime     string           `json:"start_time"`
+	EndTime       string           `json:"end_time"`
+	Items         []*StatementItem `json:"items"`
+	TotalQuantity int              `json:"total_quantity"`
+	TotalAmount   float64          `json:"total_amount"`
+	RefundAmount  float64          `json:"refund_amount"`
+	PaidAmount    float64          `json:"paid_amount"`
+	UnpaidAmount  float64          `json:"unpaid_amount"`
+}
+
+// GetCustomerStatement 生成客户在时间范围内的对账单，客户名需完全一致
+func GetCustomerStatement(customerName, startTime, endTime string) (*Statement, error) {
+	st := &Statement{
+		CustomerName: strings.TrimSpace(customerName),
+		StartTime:    startTime,
+		EndTime:      endTime,
+		Items:        []*StatementItem{},
+	}
+
+	query := `SELECT id, created_at, COALESCE(photo, ''), COALESCE(brand, ''), COALESCE(size, ''),
+		COALESCE(quantity, 0), COALESCE(price_rmb, 0), refunded_amount, paid_amount, paid
+		FROM cc_product WHERE customer_name=?`
+	args := []interface{}{st.CustomerName}
+	if startTime != "" {
+		query += " AND created_at >= ?"
+		args = append(args, startTime)
+	}
+	if endTime != "" {
+		query += " AND created_at <= ?"
+		args = append(args, endTime)
+	}
+	query += " ORDER BY created_at ASC, id ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &StatementItem{}
+		err := rows.Scan(&item.ID, &item.CreatedAt, &item.Photo, &item.Brand, &item.Size,
+			&item.Quantity, &item.PriceRMB, &item.Refunded, &item.PaidAmount, &item.Paid)
+		if err != nil {
+			return nil, err
+		}
+		item.CreatedAt = sortTimeValue(item.CreatedAt)
+
+		st.TotalQuantity += item.Quantity
+		st.TotalAmount += item.PriceRMB
+		st.RefundAmount += item.Refunded
+		st.PaidAmount += item.PaidAmount
+		st.UnpaidAmount += item.PriceRMB - item.Refunded - item.PaidAmount
+		st.Items = append(st.Items, item)
+	}
+	return st, rows.Err()
+}
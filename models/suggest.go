@@ -0,0 +1,175 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sorting-system/database"
+	"strings"
+	"time"
+)
+
+// Suggestion 输入联想的候选值，Count 为使用次数，LastUsed 为最近一次使用时间
+type Suggestion struct {
+	Value    string `json:"value"`
+	Count    int    `json:"count"`
+	LastUsed string `json:"last_used"`
+}
+
+// suggestSource 联想字段取值的表和列
+type suggestSource struct {
+	table  string
+	column string
+}
+
+var suggestSources = map[string]suggestSource{
+	"customer_name":  {"cc_product", "customer_name"},
+	"address":        {"cc_product", "address"},
+	"brand":          {"cc_product", "brand"},
+	"size":           {"cc_product", "size"},
+	"confirm_person": {"cc_arrival", "confirm_person"},
+}
+
+// suggestWindow 联想只统计最近的记录条数，保证每次按键查询的耗时稳定
+const suggestWindow = 10000
+
+// IsSuggestField 是否支持输入联想的字段
+func IsSuggestField(field string) bool {
+	_, ok := suggestSources[field]
+	return ok
+}
+
+// suggestScore 按使用次数和最近使用时间打分，30天前使用的权重减半
+func suggestScore(count int, lastUsed time.Time, now time.Time) float64 {
+	days := now.Sub(lastUsed).Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+	return float64(count) / (1 + days/30)
+}
+
+// Suggest 按历史记录联想字段值，前缀匹配的排在前面，其余按使用次数和最近使用时间排序；
+// 非管理员只使用自己录入的记录
+func Suggest(field, q string, userID, limit int) ([]*Suggestion, error) {
+	src, ok := suggestSources[field]
+	if !ok {
+		return nil, fmt.Errorf("不支持的字段: %s", field)
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	q = strings.TrimSpace(q)
+
+	scope, args := "1=1", []interface{}{}
+	if userID != 1 {
+		scope = "user_id=?"
+		args = append(args, userID)
+	}
+	args = append(args, likePattern(q))
+
+	rows, err := database.DB.Query(
+		fmt.Sprintf(`SELECT TRIM(t.v), COUNT(*), MAX(t.created_at) FROM (
+			SELECT %s AS v, created_at FROM %s WHERE %s ORDER BY id DESC LIMIT %d
+		) t
+		WHERE TRIM(COALESCE(t.v, '')) <> '' AND t.v LIKE ?
+		GROUP BY TRIM(t.v)
+		ORDER BY COUNT(*) DESC
+		LIMIT 200`, src.column, src.table, scope, suggestWindow),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type scored struct {
+		*Suggestion
+		prefix bool
+		score  float64
+	}
+	now := time.Now()
+	lower := strings.ToLower(q)
+	candidates := []scored{}
+	for rows.Next() {
+		s := &Suggestion{}
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&s.Value, &s.Count, &lastUsed); err != nil {
+			return nil, err
+		}
+		if lastUsed.Valid {
+			s.LastUsed = lastUsed.Time.Format("2006-01-02 15:04:05")
+		}
+		candidates = append(candidates, scored{
+			Suggestion: s,
+			prefix:     strings.HasPrefix(strings.ToLower(s.Value), lower),
+			score:      suggestScore(s.Count, lastUsed.Time, now),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].prefix != candidates[j].prefix {
+			return candidates[i].prefix
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	list := make([]*Suggestion, 0, limit)
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if len(list) == limit {
+			break
+		}
+		list = append(list, c.Suggestion)
+		seen[strings.ToLower(c.Value)] = true
+	}
+
+	// 品牌历史不足时补充品牌目录中的名称
+	if field == "brand" && len(list) < limit && q != "" {
+		brands, err := SuggestBrands(q, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range brands {
+			if len(list) == limit {
+				break
+			}
+			if !seen[strings.ToLower(b.Name)] {
+				list = append(list, &Suggestion{Value: b.Name})
+				seen[strings.ToLower(b.Name)] = true
+			}
+		}
+	}
+	return list, nil
+}
+
+// GetCustomerAddresses 客户最近使用的收件地址，最新的在前，最多5个
+func GetCustomerAddresses(customerName string, userID int) ([]string, error) {
+	query := `SELECT TRIM(address), MAX(id) FROM cc_product
+		WHERE customer_name=? AND TRIM(COALESCE(address, '')) <> ''`
+	args := []interface{}{strings.TrimSpace(customerName)}
+	if userID != 1 {
+		query += " AND user_id=?"
+		args = append(args, userID)
+	}
+	query += " GROUP BY TRIM(address) ORDER BY MAX(id) DESC LIMIT 5"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]string, 0)
+	for rows.Next() {
+		var address string
+		var lastID int
+		if err := rows.Scan(&address, &lastID); err != nil {
+			return nil, err
+		}
+		list = append(list, address)
+	}
+	return list, rows.Err()
+}
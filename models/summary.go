@@ -0,0 +1,231 @@
+package models
+
+import (
+	"fmt"
+	"sorting-system/database"
+	"strings"
+)
+
+// 分组汇总维度
+const (
+	SummaryByArea     = "area"
+	SummaryByBrand    = "brand"
+	SummaryByCustomer = "customer"
+	SummaryByStatus   = "status"
+	SummaryByDay      = "day"
+	SummaryByWeek     = "week"
+	SummaryByMonth    = "month"
+)
+
+// 商品状态，取包裹发货、扫码分拣、关联到货记录中最靠后的一步
+const (
+	ArrivalStatusArrived = "arrived"
+	ArrivalStatusPending = "pending"
+	// ArrivalStatusSorted 已扫码分拣，尚未装入发货包裹
+	ArrivalStatusSorted = "sorted"
+)
+
+// statusLabels 状态维度的展示名称；已装包的商品按包裹状态 packed/shipped/delivered 分组
+var statusLabels = map[string]string{
+	ArrivalStatusPending: "未到货",
+	ArrivalStatusArrived: "已到货",
+	ArrivalStatusSorted:  "已分拣",
+	ParcelPacked:         "已打包",
+	ParcelShipped:        "已发出",
+	ParcelDelivered:      "已送达",
+}
+
+// summaryDimensions 维度对应的分组表达式，作用于 cc_product
+var summaryDimensions = map[string]string{
+	SummaryByArea:     "COALESCE(area_id, 0)",
+	SummaryByBrand:    "COALESCE(brand, '')",
+	SummaryByCustomer: "COALESCE(customer_name, '')",
+	SummaryByStatus: "CASE WHEN COALESCE(outbound_status, '') <> '' THEN outbound_status" +
+		" WHEN sorted_at IS NOT NULL THEN '" + ArrivalStatusSorted + "'" +
+		" WHEN EXISTS (SELECT 1 FROM cc_arrival_item ai WHERE ai.product_id = cc_product.id) THEN '" + ArrivalStatusArrived + "'" +
+		" ELSE '" + ArrivalStatusPending + "' END",
+	SummaryByDay:   "DATE_FORMAT(created_at, '%Y-%m-%d')",
+	SummaryByWeek:  "DATE_FORMAT(created_at, '%x-W%v')",
+	SummaryByMonth: "DATE_FORMAT(created_at, '%Y-%m')",
+}
+
+// IsSummaryDimension 判断是否为支持的分组维度
+func IsSummaryDimension(dim string) bool {
+	_, ok := summaryDimensions[dim]
+	return ok
+}
+
+// GroupSummary 一个分组的汇总，Keys/Labels 与请求的维度一一对应
+type GroupSummary struct {
+	Keys             []string `json:"keys"`
+	Labels           []string `json:"labels"`
+	Count            int      `json:"count"`
+	TotalCostEur     float64  `json:"total_cost_eur"`
+	TotalCostRMB     float64  `json:"total_cost_rmb"`
+	TotalPriceRMB    float64  `json:"total_price_rmb"`
+	TotalShippingFee float64  `json:"total_shipping_fee"`
+	TotalVatRefund   float64  `json:"total_vat_refund"`
+	TotalCardFee     float64  `json:"total_card_fee"`
+	TotalCommission  float64  `json:"total_commission"`
+	TotalOtherCost   float64  `json:"total_other_cost"`
+	TotalCost        float64  `json:"total_cost"`
+	TotalProfit      float64  `json:"total_profit"`
+	TotalQuantity    int      `json:"total_quantity"`
+	TotalReceived    float64  `json:"total_received"`
+	TotalOutstanding float64  `json:"total_outstanding"`
+}
+
+// GroupedSummaryResponse 分组汇总结果
+type GroupedSummaryResponse struct {
+	Dimensions []string        `json:"dimensions"`
+	Groups     []*GroupSummary `json:"groups"`
+}
+
+// GetGroupedSummary 按一到两个维度分组汇总符合条件的商品，金额字段的可见性与 GetSummary 一致
+func GetGroupedSummary(userID int, dims []string, whereClause string, args []interface{}) (*GroupedSummaryResponse, error) {
+	if len(dims) == 0 || len(dims) > 2 {
+		return nil, fmt.Errorf("分组维度需为1到2个")
+	}
+
+	keys := make([]string, len(dims))
+	for i, dim := range dims {
+		expr, ok := summaryDimensions[dim]
+		if !ok {
+			return nil, fmt.Errorf("不支持的分组维度: %s", dim)
+		}
+		keys[i] = fmt.Sprintf("%s AS k%d", expr, i+1)
+	}
+
+	groupBy := "k1"
+	if len(dims) == 2 {
+		groupBy = "k1, k2"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s,
+			COUNT(*),
+			COALESCE(SUM(cost_eur), 0),
+			COALESCE(SUM(cost_rmb), 0),
+			COALESCE(SUM(price_rmb), 0),
+			COALESCE(SUM(shipping_fee), 0),
+			COALESCE(SUM(vat_refund), 0),
+			COALESCE(SUM(card_fee), 0),
+			COALESCE(SUM(commission), 0),
+			COALESCE(SUM(other_cost), 0),
+			COALESCE(SUM(total_cost), 0),
+			COALESCE(SUM(profit), 0),
+			COALESCE(SUM(quantity), 0),
+			COALESCE(SUM(paid_amount), 0),
+			COALESCE(SUM(refunded_amount), 0)
+		FROM cc_product
+		%s
+		GROUP BY %s
+		ORDER BY %s
+	`, strings.Join(keys, ", "), whereClause, groupBy, groupBy)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &GroupedSummaryResponse{Dimensions: dims, Groups: []*GroupSummary{}}
+	for rows.Next() {
+		g := &GroupSummary{Keys: make([]string, len(dims))}
+		var refunded float64
+		dest := make([]interface{}, 0, len(dims)+14)
+		for i := range g.Keys {
+			dest = append(dest, &g.Keys[i])
+		}
+		dest = append(dest, &g.Count, &g.TotalCostEur, &g.TotalCostRMB, &g.TotalPriceRMB,
+			&g.TotalShippingFee, &g.TotalVatRefund, &g.TotalCardFee, &g.TotalCommission, &g.TotalOtherCost,
+			&g.TotalCost, &g.TotalProfit, &g.TotalQuantity, &g.TotalReceived, &refunded)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		g.TotalOutstanding = g.TotalPriceRMB - refunded - g.TotalReceived
+
+		if userID != 1 {
+			g.TotalCostEur = 0.0
+			g.TotalCostRMB = 0.0
+			g.TotalPriceRMB = 0.0
+			g.TotalShippingFee = 0.0
+			g.TotalVatRefund = 0.0
+			g.TotalCardFee = 0.0
+			g.TotalCommission = 0.0
+			g.TotalOtherCost = 0.0
+			g.TotalCost = 0.0
+			g.TotalProfit = 0.0
+			g.TotalReceived = 0.0
+			g.TotalOutstanding = 0.0
+		}
+		resp.Groups = append(resp.Groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := labelGroups(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// labelGroups 为分组键生成展示名称，区域ID换成区域名称
+func labelGroups(resp *GroupedSummaryResponse) error {
+	var areaNames map[string]string
+	for _, dim := range resp.Dimensions {
+		if dim == SummaryByArea {
+			names, err := areaNameMap()
+			if err != nil {
+				return err
+			}
+			areaNames = names
+		}
+	}
+
+	for _, g := range resp.Groups {
+		g.Labels = make([]string, len(g.Keys))
+		for i, key := range g.Keys {
+			label := key
+			switch resp.Dimensions[i] {
+			case SummaryByArea:
+				if name, ok := areaNames[key]; ok {
+					label = name
+				} else {
+					label = "未分区"
+				}
+			case SummaryByStatus:
+				if name, ok := statusLabels[key]; ok {
+					label = name
+				}
+			default:
+				if label == "" {
+					label = "未填写"
+				}
+			}
+			g.Labels[i] = label
+		}
+	}
+	return nil
+}
+
+// areaNameMap 区域ID（字符串）到名称的映射
+func areaNameMap() (map[string]string, error) {
+	rows, err := database.DB.Query(`SELECT id, name FROM cc_product_area`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]string{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[fmt.Sprint(id)] = name
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sorting-system/database"
+	"strings"
+)
+
+type Tag struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// TagSummary 单个标签下商品的数量与金额汇总
+type TagSummary struct {
+	TagID         int     `json:"tag_id"`
+	Name          string  `json:"name"`
+	Color         string  `json:"color"`
+	Count         int     `json:"count"`
+	TotalQuantity int     `json:"total_quantity"`
+	TotalPriceRMB float64 `json:"total_price_rmb"`
+	TotalCost     float64 `json:"total_cost"`
+	TotalProfit   float64 `json:"total_profit"`
+}
+
+// CreateTag 创建标签
+func CreateTag(t *Tag) error {
+	if t.Color == "" {
+		t.Color = "#909399"
+	}
+	result, err := database.DB.Exec(
+		`INSERT INTO cc_tag (user_id, name, color) VALUES (?, ?, ?)`,
+		t.UserID, t.Name, t.Color,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t.ID = int(id)
+	return nil
+}
+
+// UpdateTag 更新标签
+func UpdateTag(t *Tag) error {
+	_, err := database.DB.Exec(
+		`UPDATE cc_tag SET name=?, color=? WHERE id=? AND user_id=?`,
+		t.Name, t.Color, t.ID, t.UserID,
+	)
+	return err
+}
+
+// DeleteTag 删除标签及其与商品的关联
+func DeleteTag(id, userID int) error {
+	result, err := database.DB.Exec(`DELETE FROM cc_tag WHERE id=? AND user_id=?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+	_, err = database.DB.Exec(`DELETE FROM cc_product_tag WHERE tag_id=?`, id)
+	return err
+}
+
+// GetTagByID 根据ID获取标签
+func GetTagByID(id int) (*Tag, error) {
+	t := &Tag{}
+	err := database.DB.QueryRow(
+		`SELECT id, user_id, name, color, created_at, updated_at FROM cc_tag WHERE id=?`,
+		id,
+	).Scan(&t.ID, &t.UserID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTagList 获取标签列表
+func GetTagList() ([]*Tag, error) {
+	rows, err := database.DB.Query(
+		`SELECT id, user_id, name, color, created_at, updated_at FROM cc_tag ORDER BY id ASC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*Tag, 0)
+	for rows.Next() {
+		t := &Tag{}
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// ErrTagNotFound 要添加的标签不存在
+var ErrTagNotFound = errors.New("标签不存在")
+
+// AddProductTags 批量为商品添加标签，已存在的关联忽略
+// 有标签不存在时不做任何添加，返回包装了 ErrTagNotFound 的错误
+func AddProductTags(productIDs, tagIDs []int) error {
+	if len(productIDs) == 0 || len(tagIDs) == 0 {
+		return nil
+	}
+	missing, err := missingIDs("cc_tag", tagIDs)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %v", ErrTagNotFound, missing)
+	}
+
+	values := make([]string, 0, len(productIDs)*len(tagIDs))
+	args := make([]interface{}, 0, len(productIDs)*len(tagIDs)*2)
+	for _, productID := range productIDs {
+		for _, tagID := range tagIDs {
+			values = append(values, "(?, ?)")
+			args = append(args, productID, tagID)
+		}
+	}
+
+	query := fmt.Sprintf("INSERT IGNORE INTO cc_product_tag (product_id, tag_id) VALUES %s",
+		strings.Join(values, ","))
+	_, err = database.DB.Exec(query, args...)
+	return err
+}
+
+// RemoveProductTags 批量移除商品的标签
+func RemoveProductTags(productIDs, tagIDs []int) error {
+	if len(productIDs) == 0 || len(tagIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(productIDs)+len(tagIDs))
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	for _, id := range tagIDs {
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf("DELETE FROM cc_product_tag WHERE product_id IN (%s) AND tag_id IN (%s)",
+		placeholders(len(productIDs)), placeholders(len(tagIDs)))
+	_, err := database.DB.Exec(query, args...)
+	return err
+}
+
+// attachProductTags 为商品列表批量加载标签
+func attachProductTags(list []*Product) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*Product, len(list))
+	args := make([]interface{}, len(list))
+	for i, p := range list {
+		p.Tags = []*Tag{}
+		byID[p.ID] = p
+		args[i] = p.ID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT pt.product_id, t.id, t.user_id, t.name, t.color, t.created_at, t.updated_at
+		FROM cc_product_tag pt
+		JOIN cc_tag t ON t.id = pt.tag_id
+		WHERE pt.product_id IN (%s)
+		ORDER BY t.id ASC
+	`, placeholders(len(list)))
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		t := &Tag{}
+		if err := rows.Scan(&productID, &t.ID, &t.UserID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return err
+		}
+		if p, ok := byID[productID]; ok {
+			p.Tags = append(p.Tags, t)
+		}
+	}
+	return rows.Err()
+}
+
+// GetTagSummaries 按标签汇总符合条件的商品，whereClause 作用于 cc_product
+func GetTagSummaries(userID int, whereClause string, args []interface{}) ([]*TagSummary, error) {
+	query := fmt.Sprintf(`
+		SELECT t.id, t.name, t.color,
+			COUNT(*),
+			COALESCE(SUM(p.quantity), 0),
+			COALESCE(SUM(p.price_rmb), 0),
+			COALESCE(SUM(p.total_cost), 0),
+			COALESCE(SUM(p.profit), 0)
+		FROM cc_product_tag pt
+		JOIN cc_tag t ON t.id = pt.tag_id
+		JOIN (SELECT id, quantity, price_rmb, total_cost, profit FROM cc_product %s) p ON p.id = pt.product_id
+		GROUP BY t.id, t.name, t.color
+		ORDER BY t.id ASC
+	`, whereClause)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*TagSummary, 0)
+	for rows.Next() {
+		s := &TagSummary{}
+		err := rows.Scan(&s.TagID, &s.Name, &s.Color, &s.Count, &s.TotalQuantity, &s.TotalPriceRMB, &s.TotalCost, &s.TotalProfit)
+		if err != nil {
+			return nil, err
+		}
+		if userID != 1 {
+			s.TotalPriceRMB = 0.0
+			s.TotalCost = 0.0
+			s.TotalProfit = 0.0
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// placeholders 生成 n 个以逗号分隔的占位符
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?,", n-1) + "?"
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"database/sql"
+	"sorting-system/database"
+	"sorting-system/validate"
+)
+
+// ProductSchema 商品可写字段的校验规则，也是 UpdateProductField 支持的字段
+var ProductSchema = validate.Schema{
+	"area_id":           {Kind: validate.KindInt, Nullable: true, Positive: true, Label: "区域"},
+	"photo":             {MaxLen: 500, Label: "照片"},
+	"customer_name":     {MaxLen: 200, Label: "客户名"},
+	"brand":             {MaxLen: 512, Label: "品牌"},
+	"size":              {MaxLen: 50, Label: "尺码"},
+	"address":           {Label: "收件地址"},
+	"mark":              {MaxLen: 1000, Label: "备注"},
+	"status_note_photo": {MaxLen: 500, Label: "货物状态备注图片"},
+	"cost_eur":          {Kind: validate.KindNumber, NonNeg: true, Label: "成本欧元"},
+	"exchange_rate":     {Kind: validate.KindNumber, NonNeg: true, Label: "汇率"},
+	"price_rmb":         {Kind: validate.KindNumber, NonNeg: true, Label: "售价"},
+	"shipping_fee":      {Kind: validate.KindNumber, NonNeg: true, Label: "运费"},
+	"weight":            {Kind: validate.KindNumber, NonNeg: true, Label: "重量"},
+	"length":            {Kind: validate.KindNumber, NonNeg: true, Label: "长"},
+	"width":             {Kind: validate.KindNumber, NonNeg: true, Label: "宽"},
+	"height":            {Kind: validate.KindNumber, NonNeg: true, Label: "高"},
+	"route":             {MaxLen: 100, Label: "运输线路"},
+}
+
+// ArrivalSchema 到货记录可写字段的校验规则
+var ArrivalSchema = validate.Schema{
+	"arrival_photo":  {MaxLen: 500, Label: "到货照片"},
+	"quantity":       {MaxLen: 100, Label: "到货件数"},
+	"brand":          {MaxLen: 200, Label: "到货品牌"},
+	"box_number":     {MaxLen: 200, Label: "箱号"},
+	"arrival_date":   {Kind: validate.KindDate, MaxLen: 100, Label: "到货日期"},
+	"confirm_person": {MaxLen: 100, Label: "确认人员"},
+	"weight":         {Kind: validate.KindNumber, NonNeg: true, Label: "重量"},
+	"length":         {Kind: validate.KindNumber, NonNeg: true, Label: "长"},
+	"width":          {Kind: validate.KindNumber, NonNeg: true, Label: "宽"},
+	"height":         {Kind: validate.KindNumber, NonNeg: true, Label: "高"},
+}
+
+// fieldValue 字段名与值，按固定顺序校验使错误顺序稳定
+type fieldValue struct {
+	field string
+	value interface{}
+}
+
+// checkFields 按规则逐个校验字段
+func checkFields(schema validate.Schema, values []fieldValue) validate.Errors {
+	var errs validate.Errors
+	for _, fv := range values {
+		if fe := schema.Check(fv.field, fv.value); fe != nil {
+			errs = append(errs, fe)
+		}
+	}
+	return errs
+}
+
+// changedFields 只保留与修改前不同的字段
+func changedFields(values, oldValues []fieldValue) []fieldValue {
+	changed := make([]fieldValue, 0, len(values))
+	for i, fv := range values {
+		if !sameValue(fv.value, oldValues[i].value) {
+			changed = append(changed, fv)
+		}
+	}
+	return changed
+}
+
+// productFieldValues 商品可写字段的当前值
+func productFieldValues(p *Product) []fieldValue {
+	return []fieldValue{
+		{"area_id", p.AreaID},
+		{"photo", p.Photo},
+		{"customer_name", p.CustomerName},
+		{"brand", p.Brand},
+		{"size", p.Size},
+		{"address", p.Address},
+		{"mark", p.Mark},
+		{"status_note_photo", p.StatusNotePhoto},
+		{"cost_eur", p.CostEur},
+		{"exchange_rate", p.ExchangeRate},
+		{"price_rmb", p.PriceRMB},
+		{"shipping_fee", p.ShippingFee},
+		{"weight", p.Weight},
+		{"length", p.Length},
+		{"width", p.Width},
+		{"height", p.Height},
+		{"route", p.Route},
+	}
+}
+
+// ValidateProduct 校验商品字段；old 为修改前的商品，此时只校验有变化的字段，
+// 避免历史数据不合规时无法修改其他字段。userID 为操作用户，用于检查区域的归属
+func ValidateProduct(p *Product, old *Product, userID int) error {
+	values := productFieldValues(p)
+	if old != nil {
+		values = changedFields(values, productFieldValues(old))
+	}
+	errs := checkFields(ProductSchema, values)
+
+	// 有欧元成本时必须有汇率，否则人民币成本为0
+	costChanged := old == nil || p.CostEur != old.CostEur || p.ExchangeRate != old.ExchangeRate
+	if costChanged && p.CostEur > 0 && p.ExchangeRate <= 0 {
+		errs.Add("exchange_rate", validate.CodePositive, "填写成本欧元时汇率必须大于0")
+	}
+
+	routeChanged := old == nil || p.Route != old.Route
+	if routeChanged && p.Route != "" && len(RateCards()) > 0 {
+		if _, err := findRateCard(p.Route); err != nil {
+			errs.Add("route", validate.CodeNotFound, err.Error())
+		}
+	}
+
+	areaChanged := old == nil || !sameValue(p.AreaID, old.AreaID)
+	if p.AreaID != nil && *p.AreaID > 0 && areaChanged {
+		fe, err := checkArea(*p.AreaID, userID)
+		if err != nil {
+			return err
+		}
+		if fe != nil {
+			errs = append(errs, fe)
+		}
+	}
+	return errs.Err()
+}
+
+// sameValue 比较字段值，*int 按指向的值比较
+func sameValue(a, b interface{}) bool {
+	pa, ok := a.(*int)
+	if !ok {
+		return a == b
+	}
+	pb := b.(*int)
+	if pa == nil || pb == nil {
+		return pa == pb
+	}
+	return *pa == *pb
+}
+
+// checkArea 区域必须存在，非管理员只能使用自己创建的区域
+func checkArea(areaID, userID int) (*validate.FieldError, error) {
+	var ownerID int
+	err := database.DB.QueryRow(`SELECT user_id FROM cc_product_area WHERE id=?`, areaID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return &validate.FieldError{Field: "area_id", Code: validate.CodeNotFound, Message: "区域不存在"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID != 1 && ownerID != userID {
+		return &validate.FieldError{Field: "area_id", Code: validate.CodeForbidden, Message: "不能使用其他用户的区域"}, nil
+	}
+	return nil, nil
+}
+
+// arrivalFieldValues 到货记录可写字段的当前值
+func arrivalFieldValues(a *Arrival) []fieldValue {
+	return []fieldValue{
+		{"arrival_photo", a.ArrivalPhoto},
+		{"quantity", a.Quantity},
+		{"brand", a.Brand},
+		{"box_number", a.BoxNumber},
+		{"arrival_date", a.ArrivalDate},
+		{"confirm_person", a.ConfirmPerson},
+		{"weight", a.Weight},
+		{"length", a.Length},
+		{"width", a.Width},
+		{"height", a.Height},
+	}
+}
+
+// ValidateArrival 校验到货记录字段；old 为修改前的记录，此时只校验有变化的字段
+func ValidateArrival(a *Arrival, old *Arrival) error {
+	values := arrivalFieldValues(a)
+	if old != nil {
+		values = changedFields(values, arrivalFieldValues(old))
+	}
+	return checkFields(ArrivalSchema, values).Err()
+}
@@ -0,0 +1,244 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sorting-system/database"
+	"sorting-system/filter"
+)
+
+const (
+	ViewListProduct = "product"
+	ViewListArrival = "arrival"
+)
+
+// SavedView 保存的列表视图（筛选、排序、可见列、每页条数）
+type SavedView struct {
+	ID        int            `json:"id"`
+	UserID    int            `json:"user_id"`
+	ListType  string         `json:"list_type"`
+	Name      string         `json:"name"`
+	Filter    *filter.Filter `json:"filter"`
+	OrderBy   string         `json:"order_by"`
+	OrderDir  string         `json:"order_dir"`
+	Columns   []string       `json:"columns"`
+	PageSize  int            `json:"page_size"`
+	IsShared  bool           `json:"is_shared"`
+	IsDefault bool           `json:"is_default"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+}
+
+const savedViewColumns = `id, user_id, list_type, name, filter, order_by, order_dir, columns, page_size,
+		is_shared, is_default, created_at, updated_at`
+
+func scanSavedView(row rowScanner) (*SavedView, error) {
+	v := &SavedView{}
+	var filterJSON, columnsJSON string
+	err := row.Scan(
+		&v.ID, &v.UserID, &v.ListType, &v.Name, &filterJSON, &v.OrderBy, &v.OrderDir, &columnsJSON, &v.PageSize,
+		&v.IsShared, &v.IsDefault, &v.CreatedAt, &v.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	v.Filter = &filter.Filter{}
+	if filterJSON != "" {
+		if err := json.Unmarshal([]byte(filterJSON), v.Filter); err != nil {
+			return nil, err
+		}
+	}
+	v.Columns = []string{}
+	if columnsJSON != "" {
+		if err := json.Unmarshal([]byte(columnsJSON), &v.Columns); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// normalize 补全默认值并序列化 JSON 字段
+func (v *SavedView) normalize() (string, string, error) {
+	if v.ListType != ViewListArrival {
+		v.ListType = ViewListProduct
+	}
+	if v.Filter == nil {
+		v.Filter = &filter.Filter{}
+	}
+	if v.Columns == nil {
+		v.Columns = []string{}
+	}
+	if v.OrderDir != "ASC" {
+		v.OrderDir = "DESC"
+	}
+	filterJSON, err := json.Marshal(v.Filter)
+	if err != nil {
+		return "", "", err
+	}
+	columnsJSON, err := json.Marshal(v.Columns)
+	if err != nil {
+		return "", "", err
+	}
+	return string(filterJSON), string(columnsJSON), nil
+}
+
+// CreateSavedView 创建视图
+func CreateSavedView(v *SavedView) error {
+	filterJSON, columnsJSON, err := v.normalize()
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if v.IsDefault {
+		if err := clearDefaultView(tx, v.UserID, v.ListType); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO cc_saved_view
+		(user_id, list_type, name, filter, order_by, order_dir, columns, page_size, is_shared, is_default)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		v.UserID, v.ListType, v.Name, filterJSON, v.OrderBy, v.OrderDir, columnsJSON, v.PageSize, v.IsShared, v.IsDefault,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	v.ID = int(id)
+	return tx.Commit()
+}
+
+// UpdateSavedView 更新视图，只能修改自己的视图；视图不存在或属于他人时返回 sql.ErrNoRows
+func UpdateSavedView(v *SavedView) error {
+	filterJSON, columnsJSON, err := v.normalize()
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 先确认归属再清除默认视图，避免修改他人视图失败时仍清掉了自己的默认视图；
+	// MySQL 在值未变化时 RowsAffected 为0，因此不能用它判断视图是否存在
+	var id int
+	if err := tx.QueryRow(
+		`SELECT id FROM cc_saved_view WHERE id=? AND user_id=? FOR UPDATE`, v.ID, v.UserID,
+	).Scan(&id); err != nil {
+		return err
+	}
+
+	if v.IsDefault {
+		if err := clearDefaultView(tx, v.UserID, v.ListType); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE cc_saved_view SET
+		list_type=?, name=?, filter=?, order_by=?, order_dir=?, columns=?, page_size=?, is_shared=?, is_default=?
+		WHERE id=? AND user_id=?`,
+		v.ListType, v.Name, filterJSON, v.OrderBy, v.OrderDir, columnsJSON, v.PageSize, v.IsShared, v.IsDefault,
+		v.ID, v.UserID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteSavedView 删除视图，视图不存在或属于他人时返回 sql.ErrNoRows
+func DeleteSavedView(id, userID int) error {
+	result, err := database.DB.Exec(`DELETE FROM cc_saved_view WHERE id=? AND user_id=?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetSavedViewByID 获取自己的或他人共享的视图
+func GetSavedViewByID(id, userID int) (*SavedView, error) {
+	v, err := scanSavedView(database.DB.QueryRow(
+		`SELECT `+savedViewColumns+` FROM cc_saved_view WHERE id=? AND (user_id=? OR is_shared=1)`,
+		id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetDefaultView 获取用户在某个列表上的默认视图，没有则返回 nil
+func GetDefaultView(userID int, listType string) (*SavedView, error) {
+	v, err := scanSavedView(database.DB.QueryRow(
+		`SELECT `+savedViewColumns+` FROM cc_saved_view WHERE user_id=? AND list_type=? AND is_default=1 LIMIT 1`,
+		userID, listType,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetSavedViewList 获取自己的视图和他人共享的视图
+func GetSavedViewList(userID int, listType string) ([]*SavedView, error) {
+	query := `SELECT ` + savedViewColumns + ` FROM cc_saved_view WHERE (user_id=? OR is_shared=1)`
+	args := []interface{}{userID}
+	if listType != "" {
+		query += " AND list_type=?"
+		args = append(args, listType)
+	}
+	query += " ORDER BY user_id<>? ASC, name ASC"
+	args = append(args, userID)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*SavedView, 0)
+	for rows.Next() {
+		v, err := scanSavedView(rows)
+		if err != nil {
+			return nil, err
+		}
+		// 他人共享的视图不能作为自己的默认视图
+		if v.UserID != userID {
+			v.IsDefault = false
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
+
+func clearDefaultView(db sqlRunner, userID int, listType string) error {
+	_, err := db.Exec(
+		`UPDATE cc_saved_view SET is_default=0 WHERE user_id=? AND list_type=?`,
+		userID, listType,
+	)
+	return err
+}
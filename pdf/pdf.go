@@ -0,0 +1,216 @@
+// Package pdf 生成简单的 PDF 文档（文字、线条、矩形和 JPEG 图片）
+//
+// 中文使用阅读器内置的 STSong-Light 字体，不嵌入字体文件；
+// 坐标以页面左上角为原点，单位为点（1/72 英寸）。
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"unicode/utf8"
+)
+
+// A4 纸张尺寸
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// MmToPt 毫米转换为点
+func MmToPt(mm float64) float64 {
+	return mm * 72 / 25.4
+}
+
+type pdfImage struct {
+	data          []byte
+	width, height int
+}
+
+// Document PDF 文档
+type Document struct {
+	Width  float64
+	Height float64
+	pages  []*bytes.Buffer
+	images []*pdfImage
+}
+
+// New 创建指定页面尺寸的文档
+func New(width, height float64) *Document {
+	return &Document{Width: width, Height: height}
+}
+
+// PageCount 当前页数
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// AddPage 新增一页，后续绘制都在该页上
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) current() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text 在 (x, y) 处绘制一行文字，y 为文字顶部
+func (d *Document) Text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(d.current(), "BT /F1 %.2f Tf %.2f %.2f Td <%s> Tj ET\n",
+		size, x, d.Height-y-size*0.88, encodeText(s))
+}
+
+// Line 绘制线段
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.current(), "%.2f w %.2f %.2f m %.2f %.2f l S\n",
+		width, x1, d.Height-y1, x2, d.Height-y2)
+}
+
+// Rect 绘制矩形边框
+func (d *Document) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.current(), "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, d.Height-y-h, w, h)
+}
+
+// FillRect 绘制黑色实心矩形
+func (d *Document) FillRect(x, y, w, h float64) {
+	fmt.Fprintf(d.current(), "%.3f %.3f %.3f %.3f re f\n", x, d.Height-y-h, w, h)
+}
+
+// Image 将图片按 JPEG 嵌入，绘制在 (x, y) 处，宽高为 w、h
+func (d *Document) Image(img image.Image, x, y, w, h float64) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return err
+	}
+	bounds := img.Bounds()
+	d.images = append(d.images, &pdfImage{data: buf.Bytes(), width: bounds.Dx(), height: bounds.Dy()})
+
+	fmt.Fprintf(d.current(), "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n",
+		w, h, x, d.Height-y-h, len(d.images))
+	return nil
+}
+
+// TextWidth 估算文字宽度，半角字符按半个字宽计算
+func TextWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		if r < 0x7f {
+			width += size / 2
+		} else {
+			width += size
+		}
+	}
+	return width
+}
+
+// Truncate 截断文字使其不超过 maxWidth，超出时以 … 结尾
+func Truncate(s string, size, maxWidth float64) string {
+	if TextWidth(s, size) <= maxWidth {
+		return s
+	}
+	ellipsis := TextWidth("…", size)
+	width := 0.0
+	for i, r := range s {
+		w := TextWidth(string(r), size)
+		if width+w+ellipsis > maxWidth {
+			return s[:i] + "…"
+		}
+		width += w
+	}
+	return s
+}
+
+// encodeText 按 UniGB-UCS2-H 编码为十六进制，基本平面以外的字符替换为 ?
+func encodeText(s string) string {
+	var buf bytes.Buffer
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		if r < 0x20 {
+			continue
+		}
+		if r > 0xffff || r == utf8.RuneError {
+			r = '?'
+		}
+		fmt.Fprintf(&buf, "%04X", r)
+	}
+	return buf.String()
+}
+
+// Bytes 输出完整的 PDF 文件内容
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// 对象编号：1 目录，2 页面树，3-5 字体，之后依次为图片、页面与内容
+	const firstImage = 6
+	firstPage := firstImage + len(d.images)
+	objects := make([][]byte, 0, firstPage+len(d.pages)*2)
+
+	objects = append(objects, []byte("<< /Type /Catalog /Pages 2 0 R >>"))
+
+	kids := &bytes.Buffer{}
+	for i := range d.pages {
+		fmt.Fprintf(kids, "%d 0 R ", firstPage+i*2)
+	}
+	objects = append(objects, []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(d.pages))))
+
+	objects = append(objects,
+		[]byte("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>"),
+		[]byte("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> "+
+			"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>"),
+		[]byte("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] "+
+			"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>"),
+	)
+
+	xobjects := &bytes.Buffer{}
+	for i, img := range d.images {
+		fmt.Fprintf(xobjects, "/Im%d %d 0 R ", i+1, firstImage+i)
+		obj := &bytes.Buffer{}
+		fmt.Fprintf(obj, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB "+
+			"/BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n", img.width, img.height, len(img.data))
+		obj.Write(img.data)
+		obj.WriteString("\nendstream")
+		objects = append(objects, obj.Bytes())
+	}
+
+	resources := fmt.Sprintf("<< /Font << /F1 3 0 R >> /XObject << %s>> >>", xobjects.String())
+	for i, page := range d.pages {
+		objects = append(objects, []byte(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>",
+			d.Width, d.Height, resources, firstPage+i*2+1,
+		)))
+		content := &bytes.Buffer{}
+		fmt.Fprintf(content, "<< /Length %d >>\nstream\n", page.Len())
+		content.Write(page.Bytes())
+		content.WriteString("\nendstream")
+		objects = append(objects, content.Bytes())
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
@@ -64,21 +64,147 @@ func SetupRouter() *gin.Engine {
 		api.PUT("/areas/:id", handlers.UpdateArea)
 		api.DELETE("/areas/:id", handlers.DeleteArea)
 
+		// 输入联想
+		api.GET("/suggest", handlers.Suggest)
+		api.GET("/suggest/address", handlers.SuggestCustomerAddress)
+
+		// 品牌目录
+		api.GET("/brands", handlers.GetBrandList)
+		api.GET("/brands/suggest", handlers.SuggestBrands)
+		api.GET("/brands/variants", handlers.GetBrandVariants)
+		api.POST("/brands", handlers.CreateBrand)
+		api.POST("/brands/merge", handlers.MergeBrands)
+		api.PUT("/brands/:id", handlers.UpdateBrand)
+		api.DELETE("/brands/:id", handlers.DeleteBrand)
+
+		// 标签管理
+		api.POST("/tags", handlers.CreateTag)
+		api.GET("/tags", handlers.GetTagList)
+		api.PUT("/tags/:id", handlers.UpdateTag)
+		api.DELETE("/tags/:id", handlers.DeleteTag)
+
 		// 商品管理
 		api.POST("/products", handlers.CreateProduct)
 		api.GET("/products", handlers.GetProductList)
+		api.POST("/products/search", handlers.SearchProducts)
+		api.GET("/products/summary/grouped", handlers.GetGroupedSummary)
+		api.GET("/products/duplicates", handlers.GetDuplicateClusters)
+		api.POST("/products/merge", handlers.MergeProducts)
 		api.GET("/products/:id", handlers.GetProduct)
 		api.PUT("/products/:id", handlers.UpdateProduct)
 		api.PATCH("/products/:id/field", handlers.UpdateProductField)
 		api.POST("/products/delete", handlers.DeleteProducts)
+		api.POST("/products/tags/add", handlers.AddProductTags)
+		api.POST("/products/tags/remove", handlers.RemoveProductTags)
+		api.POST("/products/:id/split", handlers.SplitProduct)
+		api.GET("/products/:id/history", handlers.GetProductHistory)
+		api.GET("/products/:id/attachments", handlers.GetAttachmentList)
+		api.POST("/products/:id/attachments", handlers.CreateAttachment)
+		api.POST("/products/:id/attachments/reorder", handlers.ReorderAttachments)
+		api.PUT("/products/:id/attachments/:attachment_id", handlers.UpdateAttachment)
+		api.DELETE("/products/:id/attachments/:attachment_id", handlers.DeleteAttachment)
+		api.GET("/products/:id/returns", handlers.GetReturnList)
+		api.POST("/products/:id/returns", handlers.CreateReturn)
+		api.PUT("/products/:id/returns/:return_id", handlers.UpdateReturn)
+		api.DELETE("/products/:id/returns/:return_id", handlers.DeleteReturn)
+
+		// 分拣标签
+		api.GET("/labels", handlers.PrintLabels)
+		api.GET("/labels/templates", handlers.GetLabelTemplates)
+
+		// 扫码分拣
+		api.POST("/scan", handlers.Scan)
+		api.GET("/scan/sessions", handlers.GetScanSessionList)
+		api.POST("/scan/sessions", handlers.CreateScanSession)
+		api.GET("/scan/sessions/:id", handlers.GetScanSession)
+		api.POST("/scan/sessions/:id/close", handlers.CloseScanSession)
+
+		// 拣货单
+		api.GET("/picklists", handlers.GetPickListList)
+		api.POST("/picklists", handlers.CreatePickList)
+		api.GET("/picklists/:id", handlers.GetPickList)
+		api.DELETE("/picklists/:id", handlers.DeletePickList)
 
 		// 到货图管理
 		api.POST("/arrivals", handlers.CreateArrival)
 		api.GET("/arrivals", handlers.GetArrivalList)
+		api.POST("/arrivals/search", handlers.SearchArrivals)
 		api.GET("/arrivals/:id", handlers.GetArrival)
 		api.PUT("/arrivals/:id", handlers.UpdateArrival)
 		api.PATCH("/arrivals/:id/field", handlers.UpdateArrivalField)
 		api.POST("/arrivals/delete", handlers.DeleteArrivals)
+		api.GET("/arrivals/:id/items", handlers.GetArrivalItems)
+		api.POST("/arrivals/:id/items", handlers.AddArrivalItems)
+		api.POST("/arrivals/:id/items/delete", handlers.RemoveArrivalItems)
+		api.GET("/arrivals/:id/candidates", handlers.GetArrivalCandidates)
+
+		// 到货对账
+		api.GET("/reconcile", handlers.GetReconcileReport)
+
+		// 月度损益与结账
+		api.GET("/reports/pl", handlers.GetProfitLoss)
+		api.GET("/periods", handlers.GetClosedPeriods)
+		api.POST("/periods/close", handlers.ClosePeriod)
+		api.POST("/periods/reopen", handlers.ReopenPeriod)
+		api.GET("/expenses", handlers.GetExpenseList)
+		api.POST("/expenses", handlers.CreateExpense)
+		api.PUT("/expenses/:id", handlers.UpdateExpense)
+		api.DELETE("/expenses/:id", handlers.DeleteExpense)
+
+		// 运单与运费分摊
+		api.GET("/shipments", handlers.GetShipmentList)
+		api.POST("/shipments", handlers.CreateShipment)
+		api.GET("/shipments/:id", handlers.GetShipment)
+		api.PUT("/shipments/:id", handlers.UpdateShipment)
+		api.DELETE("/shipments/:id", handlers.DeleteShipment)
+		api.POST("/shipments/:id/items", handlers.AddShipmentItems)
+		api.POST("/shipments/:id/items/delete", handlers.RemoveShipmentItems)
+		api.PUT("/shipments/:id/items/:product_id", handlers.UpdateShipmentItem)
+		api.POST("/shipments/:id/allocate", handlers.AllocateShipment)
+
+		// 发货包裹
+		api.GET("/parcels", handlers.GetParcelList)
+		api.GET("/parcels/track", handlers.TrackParcel)
+		api.POST("/parcels", handlers.CreateParcel)
+		api.GET("/parcels/:id", handlers.GetParcel)
+		api.PUT("/parcels/:id", handlers.UpdateParcel)
+		api.DELETE("/parcels/:id", handlers.DeleteParcel)
+		api.POST("/parcels/:id/items", handlers.AddParcelItems)
+		api.POST("/parcels/:id/items/delete", handlers.RemoveParcelItems)
+		api.GET("/products/:id/parcel", handlers.GetProductParcel)
+
+		// 运价与运费估算
+		api.GET("/shipping/routes", handlers.GetRateCards)
+		api.POST("/shipping/estimate", handlers.EstimateFreight)
+		api.POST("/products/shipping/confirm", handlers.ConfirmShippingFees)
+
+		// 成本与利润计算参数
+		api.GET("/pricing", handlers.GetPricing)
+		api.POST("/products/recalculate", handlers.RecalculateProducts)
+
+		// 收款
+		api.GET("/payments", handlers.GetPaymentList)
+		api.GET("/payments/balance", handlers.GetCustomerBalance)
+		api.POST("/payments", handlers.CreatePayment)
+		api.PUT("/payments/:id", handlers.UpdatePayment)
+		api.DELETE("/payments/:id", handlers.DeletePayment)
+
+		// 客户对账单
+		api.GET("/statements", handlers.GetCustomerStatement)
+
+		// 评论
+		api.GET("/comments", handlers.GetCommentList)
+		api.POST("/comments", handlers.CreateComment)
+		api.DELETE("/comments/:id", handlers.DeleteComment)
+		api.GET("/mentions/unread", handlers.GetUnreadMentions)
+		api.POST("/mentions/read", handlers.MarkMentionsRead)
+
+		// 列表视图
+		api.POST("/views", handlers.CreateSavedView)
+		api.GET("/views", handlers.GetSavedViewList)
+		api.GET("/views/:id", handlers.GetSavedView)
+		api.PUT("/views/:id", handlers.UpdateSavedView)
+		api.DELETE("/views/:id", handlers.DeleteSavedView)
 
 		// 文件上传
 		api.POST("/upload", handlers.UploadImage)
@@ -0,0 +1,342 @@
+// Package search 将搜索框中的查询语法解析为参数化的 SQL 条件
+//
+// 支持的语法（多个条件以空格分隔，彼此为 AND 关系）：
+//
+//	王             全文匹配
+//	"red bag"      短语匹配
+//	brand:celine   字段包含
+//	brand=Celine   字段等于
+//	profit>100     数值/日期比较，支持 > >= < <= = !=
+//	price_rmb:100..500  数值/日期范围
+//	-mark:退货     以 - 开头表示取反
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind 字段类型
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindNumber
+	KindDate
+)
+
+// Field 可搜索字段
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// Schema 描述一张表的可搜索字段
+type Schema struct {
+	// Fields 字段名（含别名）到列的映射
+	Fields map[string]Field
+	// FullText 全文索引包含的列，顺序必须与索引定义一致
+	FullText []string
+	// LikeColumns 没有全文索引时，全文条件用 LIKE 匹配的列
+	LikeColumns []string
+	// IDColumn 纯数字的全文条件同时匹配的ID列，为空则不匹配
+	IDColumn string
+	// TagClause 按标签筛选的条件模板，%s 处填入标签ID占位符，为空表示不支持标签筛选
+	TagClause string
+
+	// denied 不允许搜索的列，见 Restrict
+	denied map[string]bool
+}
+
+// Restrict 返回不允许按指定列搜索和筛选的副本，用于向无权查看这些列的用户隐藏取值
+func (s *Schema) Restrict(columns ...string) *Schema {
+	restricted := *s
+	restricted.denied = map[string]bool{}
+	for col := range s.denied {
+		restricted.denied[col] = true
+	}
+	for _, col := range columns {
+		restricted.denied[col] = true
+	}
+	return &restricted
+}
+
+// Allowed 是否允许按该列搜索和筛选
+func (s *Schema) Allowed(column string) bool {
+	return !s.denied[column]
+}
+
+// Term 解析后的单个查询条件
+type Term struct {
+	Field  string
+	Op     string
+	Value  string
+	Phrase bool
+	Negate bool
+}
+
+// Error 查询语法错误
+type Error struct {
+	Term string
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Term == "" {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s", e.Term, e.Msg)
+}
+
+var (
+	fieldTermRe  = regexp.MustCompile(`^([\p{L}_][\p{L}\p{N}_]*?)(>=|<=|!=|:|>|<|=)(.*)$`)
+	identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	likeEscaper  = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	// 布尔全文模式中的运算符，作为普通文本搜索时需去除
+	booleanStripper = strings.NewReplacer(`+`, " ", `-`, " ", `<`, " ", `>`, " ", `(`, " ", `)`, " ",
+		`~`, " ", `*`, " ", `"`, " ", `@`, " ")
+	dateLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04",
+		"2006-01-02", "2006-01"}
+)
+
+// Parse 将查询字符串拆分为条件，不校验字段
+func Parse(input string) ([]Term, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]Term, 0, len(tokens))
+	for _, tok := range tokens {
+		t := Term{}
+		raw := tok
+		if strings.HasPrefix(tok, "-") && len(tok) > 1 {
+			t.Negate = true
+			tok = tok[1:]
+		}
+
+		if m := fieldTermRe.FindStringSubmatch(tok); m != nil {
+			t.Field = strings.ToLower(m[1])
+			t.Op = m[2]
+			t.Value, t.Phrase = unquote(m[3])
+			if t.Value == "" {
+				return nil, &Error{Term: raw, Msg: "缺少条件值"}
+			}
+		} else {
+			t.Value, t.Phrase = unquote(tok)
+			if t.Value == "" {
+				continue
+			}
+		}
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+// Compile 解析查询字符串并生成 WHERE 条件（不含前导 AND），无条件时返回空字符串
+func (s *Schema) Compile(input string) (string, []interface{}, error) {
+	terms, err := Parse(input)
+	if err != nil {
+		return "", nil, err
+	}
+	return s.CompileTerms(terms)
+}
+
+// CompileTerms 将已解析的条件生成 WHERE 条件
+func (s *Schema) CompileTerms(terms []Term) (string, []interface{}, error) {
+	clauses := []string{}
+	args := []interface{}{}
+
+	for _, t := range terms {
+		if t.Field != "" {
+			f, ok := s.Fields[t.Field]
+			if !ok {
+				if identifierRe.MatchString(t.Field) {
+					return "", nil, &Error{Term: t.Field, Msg: "未知的搜索字段"}
+				}
+				// 非英文的前缀（如中文客户名中的冒号）按全文处理
+				t.Value = t.Field + t.Op + t.Value
+				t.Field, t.Op = "", ""
+			} else if !s.Allowed(f.Column) {
+				return "", nil, &Error{Term: t.Field, Msg: "没有权限按该字段搜索"}
+			}
+		}
+
+		var clause string
+		var clauseArgs []interface{}
+		var err error
+		if t.Field == "" {
+			clause, clauseArgs = s.compileFullText(t)
+		} else {
+			clause, clauseArgs, err = s.compileField(t)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		if t.Negate {
+			clause = "NOT (" + clause + ")"
+		}
+		clauses = append(clauses, clause)
+		args = append(args, clauseArgs...)
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+func (s *Schema) compileFullText(t Term) (string, []interface{}) {
+	value := strings.TrimSpace(booleanStripper.Replace(t.Value))
+	parts := []string{}
+	args := []interface{}{}
+
+	// ngram 默认最小词长为2，单字或没有全文索引时退回 LIKE
+	if utf8.RuneCountInString(value) < 2 || len(s.FullText) == 0 {
+		likeColumns := s.FullText
+		if len(likeColumns) == 0 {
+			likeColumns = s.LikeColumns
+		}
+		pattern := "%" + likeEscaper.Replace(t.Value) + "%"
+		for _, col := range likeColumns {
+			parts = append(parts, col+" LIKE ?")
+			args = append(args, pattern)
+		}
+	} else {
+		against := value
+		if t.Phrase {
+			against = `"` + value + `"`
+		}
+		parts = append(parts, fmt.Sprintf("MATCH(%s) AGAINST (? IN BOOLEAN MODE)", strings.Join(s.FullText, ", ")))
+		args = append(args, against)
+	}
+
+	if s.IDColumn != "" {
+		if id, err := strconv.Atoi(t.Value); err == nil {
+			parts = append(parts, s.IDColumn+"=?")
+			args = append(args, id)
+		}
+	}
+
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+func (s *Schema) compileField(t Term) (string, []interface{}, error) {
+	f := s.Fields[t.Field]
+
+	if f.Kind == KindText {
+		switch t.Op {
+		case ":":
+			return f.Column + " LIKE ?", []interface{}{"%" + likeEscaper.Replace(t.Value) + "%"}, nil
+		case "=":
+			return f.Column + "=?", []interface{}{t.Value}, nil
+		case "!=":
+			return "NOT (" + f.Column + " <=> ?)", []interface{}{t.Value}, nil
+		}
+		return "", nil, &Error{Term: t.Field + t.Op, Msg: "文本字段不支持大小比较"}
+	}
+
+	// 范围 a..b
+	if t.Op == ":" && strings.Contains(t.Value, "..") {
+		bounds := strings.SplitN(t.Value, "..", 2)
+		parts := []string{}
+		args := []interface{}{}
+		if bounds[0] != "" {
+			v, err := f.Convert(t.Field, bounds[0])
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, f.Column+" >= ?")
+			args = append(args, v)
+		}
+		if bounds[1] != "" {
+			v, err := f.Convert(t.Field, bounds[1])
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, f.Column+" <= ?")
+			args = append(args, v)
+		}
+		if len(parts) == 0 {
+			return "", nil, &Error{Term: t.Field, Msg: "范围不能为空"}
+		}
+		return "(" + strings.Join(parts, " AND ") + ")", args, nil
+	}
+
+	v, err := f.Convert(t.Field, t.Value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	op := t.Op
+	if op == ":" {
+		op = "="
+	}
+	if f.Kind == KindDate && op == "=" {
+		// 日期等于按整天匹配
+		return "DATE(" + f.Column + ") = DATE(?)", []interface{}{v}, nil
+	}
+	return fmt.Sprintf("%s %s ?", f.Column, op), []interface{}{v}, nil
+}
+
+// Convert 按字段类型转换条件值，name 仅用于错误提示
+func (f Field) Convert(name, raw string) (interface{}, error) {
+	switch f.Kind {
+	case KindNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, &Error{Term: name, Msg: "需要数字: " + raw}
+		}
+		return n, nil
+	case KindDate:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+				return t.Format("2006-01-02 15:04:05"), nil
+			}
+		}
+		return nil, &Error{Term: name, Msg: "需要日期(YYYY-MM-DD): " + raw}
+	}
+	return raw, nil
+}
+
+// tokenize 按空白拆分，双引号内的空白保留
+func tokenize(input string) ([]string, error) {
+	input = strings.ReplaceAll(input, "：", ":")
+	input = strings.NewReplacer("“", `"`, "”", `"`).Replace(input)
+
+	tokens := []string{}
+	var cur strings.Builder
+	inQuote := false
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if inQuote {
+		return nil, &Error{Msg: "引号未闭合"}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens, nil
+}
+
+// unquote 去除首尾双引号，返回是否为短语
+func unquote(s string) (string, bool) {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1], true
+	}
+	return s, false
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testSchema = &Schema{
+	Fields: map[string]Field{
+		"brand":      {Column: "brand", Kind: KindText},
+		"品牌":         {Column: "brand", Kind: KindText},
+		"profit":     {Column: "profit", Kind: KindNumber},
+		"created_at": {Column: "created_at", Kind: KindDate},
+	},
+	FullText: []string{"customer_name", "brand"},
+	IDColumn: "id",
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Term
+	}{
+		{"", []Term{}},
+		{"王", []Term{{Value: "王"}}},
+		{`"red bag"`, []Term{{Value: "red bag", Phrase: true}}},
+		{"“red bag”", []Term{{Value: "red bag", Phrase: true}}},
+		{"brand:celine", []Term{{Field: "brand", Op: ":", Value: "celine"}}},
+		{"Brand=Celine", []Term{{Field: "brand", Op: "=", Value: "Celine"}}},
+		{"品牌：LV", []Term{{Field: "品牌", Op: ":", Value: "LV"}}},
+		{"profit>=100", []Term{{Field: "profit", Op: ">=", Value: "100"}}},
+		{"profit!=0", []Term{{Field: "profit", Op: "!=", Value: "0"}}},
+		{"-mark:退货", []Term{{Field: "mark", Op: ":", Value: "退货", Negate: true}}},
+		{`brand:"saint laurent"`, []Term{{Field: "brand", Op: ":", Value: "saint laurent", Phrase: true}}},
+		{`a  "" b`, []Term{{Value: "a"}, {Value: "b"}}},
+		{"-", []Term{{Value: "-"}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		clause string
+		args   []interface{}
+	}{
+		{"empty", "  ", "", []interface{}{}},
+		{"text contains", "brand:celine", "brand LIKE ?", []interface{}{"%celine%"}},
+		{"text contains escapes wildcards", "brand:50%_off", "brand LIKE ?", []interface{}{`%50\%\_off%`}},
+		{"text equals", "brand=Celine", "brand=?", []interface{}{"Celine"}},
+		{"text not equals", "brand!=Celine", "NOT (brand <=> ?)", []interface{}{"Celine"}},
+		{"alias", "品牌:LV", "brand LIKE ?", []interface{}{"%LV%"}},
+		{"number compare", "profit>100", "profit > ?", []interface{}{100.0}},
+		{"number colon is equals", "profit:100", "profit = ?", []interface{}{100.0}},
+		{"number range", "profit:100..500", "(profit >= ? AND profit <= ?)", []interface{}{100.0, 500.0}},
+		{"open upper range", "profit:100..", "(profit >= ?)", []interface{}{100.0}},
+		{"open lower range", "profit:..500", "(profit <= ?)", []interface{}{500.0}},
+		{"date equals whole day", "created_at:2024-05-01", "DATE(created_at) = DATE(?)", []interface{}{"2024-05-01 00:00:00"}},
+		{"date month range", "created_at:2024-05..2024-06", "(created_at >= ? AND created_at <= ?)",
+			[]interface{}{"2024-05-01 00:00:00", "2024-06-01 00:00:00"}},
+		{"negation", "-brand:gucci", "NOT (brand LIKE ?)", []interface{}{"%gucci%"}},
+		{"ngram full text", "王小明", "(MATCH(customer_name, brand) AGAINST (? IN BOOLEAN MODE))", []interface{}{"王小明"}},
+		{"phrase", `"red bag"`, "(MATCH(customer_name, brand) AGAINST (? IN BOOLEAN MODE))", []interface{}{`"red bag"`}},
+		{"boolean operators stripped", "+red*", "(MATCH(customer_name, brand) AGAINST (? IN BOOLEAN MODE))", []interface{}{"red"}},
+		{"single rune falls back to LIKE", "王", "(customer_name LIKE ? OR brand LIKE ?)", []interface{}{"%王%", "%王%"}},
+		{"number also matches id", "12", "(MATCH(customer_name, brand) AGAINST (? IN BOOLEAN MODE) OR id=?)",
+			[]interface{}{"12", 12}},
+		{"non-english prefix is full text", "王:小明", "(MATCH(customer_name, brand) AGAINST (? IN BOOLEAN MODE))",
+			[]interface{}{"王:小明"}},
+		{"terms joined with AND", "brand:lv profit<0", "brand LIKE ? AND profit < ?", []interface{}{"%lv%", 0.0}},
+	}
+	for _, tt := range tests {
+		clause, args, err := testSchema.Compile(tt.input)
+		if err != nil {
+			t.Errorf("%s: Compile(%q) error: %v", tt.name, tt.input, err)
+			continue
+		}
+		if clause != tt.clause || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: Compile(%q) = %q %v, want %q %v", tt.name, tt.input, clause, args, tt.clause, tt.args)
+		}
+	}
+}
+
+func TestCompileLikeFallback(t *testing.T) {
+	s := &Schema{LikeColumns: []string{"brand", "box_number"}}
+	clause, args, err := s.Compile("celine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(brand LIKE ? OR box_number LIKE ?)"
+	if clause != want || !reflect.DeepEqual(args, []interface{}{"%celine%", "%celine%"}) {
+		t.Errorf("Compile without full-text index = %q %v, want %q", clause, args, want)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	restricted := testSchema.Restrict("profit")
+	tests := []struct {
+		name   string
+		schema *Schema
+		input  string
+		term   string
+	}{
+		{"unclosed quote", testSchema, `"red bag`, ""},
+		{"missing value", testSchema, "brand:", "brand:"},
+		{"missing negated value", testSchema, "-brand=", "-brand="},
+		{"unknown field", testSchema, "colour:red", "colour"},
+		{"number expected", testSchema, "profit>abc", "profit"},
+		{"date expected", testSchema, "created_at:yesterday", "created_at"},
+		{"text compare", testSchema, "brand>a", "brand>"},
+		{"empty range", testSchema, "profit:..", "profit"},
+		{"bad range bound", testSchema, "profit:1..x", "profit"},
+		{"restricted field", restricted, "profit>0", "profit"},
+	}
+	for _, tt := range tests {
+		_, _, err := tt.schema.Compile(tt.input)
+		var syntaxErr *Error
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%s: Compile(%q) error = %v, want *Error", tt.name, tt.input, err)
+			continue
+		}
+		if syntaxErr.Term != tt.term {
+			t.Errorf("%s: Compile(%q) error term = %q, want %q", tt.name, tt.input, syntaxErr.Term, tt.term)
+		}
+	}
+}
+
+func TestRestrictKeepsOriginal(t *testing.T) {
+	restricted := testSchema.Restrict("profit")
+	if !testSchema.Allowed("profit") || restricted.Allowed("profit") || !restricted.Allowed("brand") {
+		t.Errorf("Restrict must only affect the copy")
+	}
+	if _, _, err := testSchema.Compile("profit>0"); err != nil {
+		t.Errorf("unrestricted schema rejected profit: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package validate 按字段规则校验与转换请求参数，校验失败时返回可逐字段展示的错误
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 错误码，供前端按字段提示
+const (
+	CodeUnknownField = "unknown_field"
+	CodeInvalidType  = "invalid_type"
+	CodeRequired     = "required"
+	CodeMin          = "min"
+	CodePositive     = "positive"
+	CodeTooLong      = "too_long"
+	CodeInvalidDate  = "invalid_date"
+	CodeNotFound     = "not_found"
+	CodeForbidden    = "forbidden"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors 一次校验中所有字段的错误
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add 追加一个字段错误
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, &FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err 没有错误时返回 nil，避免返回非空接口
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Kind 字段值类型
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindNumber
+	KindInt
+	KindDate
+)
+
+// Rule 字段规则
+type Rule struct {
+	Kind     Kind
+	Nullable bool   // 允许 null，仅对 KindInt 有效，转换结果为 nil
+	Required bool   // 字符串不能为空
+	NonNeg   bool   // 数值不能小于0
+	Positive bool   // 数值必须大于0
+	MaxLen   int    // 字符串最大字符数，0 表示不限
+	Label    string // 错误提示中的字段名
+}
+
+// Schema 字段名到规则的映射
+type Schema map[string]Rule
+
+// Coerce 将 JSON 解析出的值转换为字段类型并校验，不会 panic
+//
+// 数字字段接受数字或数字字符串，文本字段接受字符串或数字，整数字段在 Nullable 时接受 null
+func (s Schema) Coerce(field string, value interface{}) (interface{}, *FieldError) {
+	rule, ok := s[field]
+	if !ok {
+		return nil, &FieldError{Field: field, Code: CodeUnknownField, Message: "不支持的字段"}
+	}
+
+	var v interface{}
+	switch rule.Kind {
+	case KindNumber:
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, rule.typeError(field, "数字")
+		}
+		v = f
+	case KindInt:
+		if value == nil && rule.Nullable {
+			return nil, nil
+		}
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return nil, rule.typeError(field, "整数")
+		}
+		v = int(f)
+	default:
+		switch x := value.(type) {
+		case nil:
+			v = ""
+		case string:
+			v = x
+		case float64:
+			v = strconv.FormatFloat(x, 'f', -1, 64)
+		case json.Number:
+			v = x.String()
+		default:
+			return nil, rule.typeError(field, "文本")
+		}
+	}
+
+	if fe := s.Check(field, v); fe != nil {
+		return nil, fe
+	}
+	return v, nil
+}
+
+// Check 校验已是字段类型的值，value 为 string、float64、int 或 *int
+func (s Schema) Check(field string, value interface{}) *FieldError {
+	rule, ok := s[field]
+	if !ok {
+		return &FieldError{Field: field, Code: CodeUnknownField, Message: "不支持的字段"}
+	}
+	label := rule.label(field)
+
+	switch v := value.(type) {
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			if rule.Required {
+				return &FieldError{Field: field, Code: CodeRequired, Message: label + "不能为空"}
+			}
+			return nil
+		}
+		if rule.MaxLen > 0 && utf8.RuneCountInString(v) > rule.MaxLen {
+			return &FieldError{Field: field, Code: CodeTooLong, Message: fmt.Sprintf("%s不能超过%d个字符", label, rule.MaxLen)}
+		}
+		if rule.Kind == KindDate && !isDate(v) {
+			return &FieldError{Field: field, Code: CodeInvalidDate, Message: label + "应为 YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS"}
+		}
+	case float64:
+		return rule.checkNumber(field, v)
+	case int:
+		return rule.checkNumber(field, float64(v))
+	case *int:
+		if v != nil {
+			return rule.checkNumber(field, float64(*v))
+		}
+	}
+	return nil
+}
+
+func (r Rule) checkNumber(field string, v float64) *FieldError {
+	label := r.label(field)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return &FieldError{Field: field, Code: CodeInvalidType, Message: label + "应为数字"}
+	}
+	if r.Positive && v <= 0 {
+		return &FieldError{Field: field, Code: CodePositive, Message: label + "必须大于0"}
+	}
+	if r.NonNeg && v < 0 {
+		return &FieldError{Field: field, Code: CodeMin, Message: label + "不能为负数"}
+	}
+	return nil
+}
+
+func (r Rule) typeError(field, kind string) *FieldError {
+	return &FieldError{Field: field, Code: CodeInvalidType, Message: r.label(field) + "应为" + kind}
+}
+
+func (r Rule) label(field string) string {
+	if r.Label != "" {
+		return r.Label
+	}
+	return field
+}
+
+// toFloat 接受数字或可解析为数字的字符串
+func toFloat(value interface{}) (float64, bool) {
+	switch x := value.(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// isDate 是否为日期或日期时间
+func isDate(s string) bool {
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05", time.RFC3339} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
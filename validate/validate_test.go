@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var testSchema = Schema{
+	"area_id":   {Kind: KindInt, Nullable: true, Positive: true, Label: "区域"},
+	"quantity":  {Kind: KindInt, Label: "件数"},
+	"cost_eur":  {Kind: KindNumber, NonNeg: true, Label: "成本欧元"},
+	"rate":      {Kind: KindNumber, Positive: true, Label: "汇率"},
+	"size":      {MaxLen: 5, Label: "尺码"},
+	"customer":  {Required: true, Label: "客户名"},
+	"arrive_at": {Kind: KindDate, Label: "到货日期"},
+}
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+		want  interface{}
+	}{
+		{"number", "cost_eur", 12.5, 12.5},
+		{"number as string", "cost_eur", " 12.5 ", 12.5},
+		{"number as json.Number", "cost_eur", json.Number("3"), 3.0},
+		{"zero is non-negative", "cost_eur", 0.0, 0.0},
+		{"int from float", "area_id", 3.0, 3},
+		{"int from string", "area_id", "7", 7},
+		{"null for nullable int", "area_id", nil, nil},
+		{"string", "size", "M", "M"},
+		{"string as number", "size", 38.0, "38"},
+		{"string as json.Number", "size", json.Number("42"), "42"},
+		{"null string is empty", "size", nil, ""},
+		{"date", "arrive_at", "2024-05-01", "2024-05-01"},
+		{"date time", "arrive_at", "2024-05-01 10:30:00", "2024-05-01 10:30:00"},
+	}
+	for _, tt := range tests {
+		got, fe := testSchema.Coerce(tt.field, tt.value)
+		if fe != nil {
+			t.Errorf("%s: Coerce(%q, %#v) error: %s", tt.name, tt.field, tt.value, fe.Message)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Coerce(%q, %#v) = %#v, want %#v", tt.name, tt.field, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCoerceErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+		code  string
+	}{
+		{"unknown field", "colour", "red", CodeUnknownField},
+		{"text for number", "cost_eur", "abc", CodeInvalidType},
+		{"NaN string", "cost_eur", "NaN", CodeInvalidType},
+		{"infinite string", "cost_eur", "Inf", CodeInvalidType},
+		{"bool for number", "cost_eur", true, CodeInvalidType},
+		{"null for number", "cost_eur", nil, CodeInvalidType},
+		{"negative", "cost_eur", -1.0, CodeMin},
+		{"negative as string", "cost_eur", "-0.01", CodeMin},
+		{"zero not positive", "rate", 0.0, CodePositive},
+		{"negative int not positive", "area_id", -2.0, CodePositive},
+		{"fraction for int", "area_id", 1.5, CodeInvalidType},
+		{"null for non-nullable int", "quantity", nil, CodeInvalidType},
+		{"object for text", "size", map[string]interface{}{}, CodeInvalidType},
+		{"array for text", "size", []interface{}{"M"}, CodeInvalidType},
+		{"too long counts runes", "size", "一二三四五六", CodeTooLong},
+		{"required blank", "customer", "  ", CodeRequired},
+		{"bad date", "arrive_at", "yesterday", CodeInvalidDate},
+	}
+	for _, tt := range tests {
+		got, fe := testSchema.Coerce(tt.field, tt.value)
+		if fe == nil {
+			t.Errorf("%s: Coerce(%q, %#v) = %#v, want error %s", tt.name, tt.field, tt.value, got, tt.code)
+			continue
+		}
+		if fe.Code != tt.code || fe.Field != tt.field {
+			t.Errorf("%s: Coerce(%q, %#v) error = %s/%s, want %s/%s", tt.name, tt.field, tt.value,
+				fe.Field, fe.Code, tt.field, tt.code)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	negative, positive := -1, 4
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+		code  string
+	}{
+		{"nil *int", "area_id", (*int)(nil), ""},
+		{"positive *int", "area_id", &positive, ""},
+		{"negative *int", "area_id", &negative, CodePositive},
+		{"int", "quantity", 2, ""},
+		{"negative float", "cost_eur", -3.5, CodeMin},
+		{"empty optional string", "size", "", ""},
+		{"max length", "size", "12345", ""},
+		{"unknown field", "colour", "red", CodeUnknownField},
+	}
+	for _, tt := range tests {
+		fe := testSchema.Check(tt.field, tt.value)
+		code := ""
+		if fe != nil {
+			code = fe.Code
+		}
+		if code != tt.code {
+			t.Errorf("%s: Check(%q, %#v) code = %q, want %q", tt.name, tt.field, tt.value, code, tt.code)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	if errs.Err() != nil {
+		t.Fatal("empty Errors must return a nil error")
+	}
+	errs.Add("cost_eur", CodeMin, "成本欧元不能为负数")
+	errs.Add("size", CodeTooLong, "尺码不能超过5个字符")
+	want := "cost_eur: 成本欧元不能为负数; size: 尺码不能超过5个字符"
+	if err := errs.Err(); err == nil || err.Error() != want {
+		t.Errorf("Errors.Err() = %v, want %q", err, want)
+	}
+}